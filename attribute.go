@@ -0,0 +1,118 @@
+package sqsc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Attribute a received message attribute with its data type
+type Attribute struct {
+	DataType string //<< String, Number, or Binary (with any custom suffix, i.e. Number.float)
+	Value    string //<< the value (String and Number types)
+	Binary   []byte //<< the value (Binary types)
+}
+
+// GetString a string attribute
+//
+// returns
+// - the value (numbers as sent, i.e. "1.5")
+// - any error (sqsc.ErrMissingAttribute if not set, sqsc.ErrAttributeType if it's binary)
+func (m Message) GetString(name string) (string, error) {
+	att, err := m.attribute(name)
+
+	if err != nil {
+		return "", err
+	}
+
+	if att.kind() == "Binary" {
+		return "", fmt.Errorf("%w: %s is %s", ErrAttributeType, name, att.DataType)
+	}
+
+	return att.Value, nil
+}
+
+// GetInt a number attribute as an integer
+//
+// returns
+// - the value
+// - any error (sqsc.ErrMissingAttribute if not set, sqsc.ErrAttributeType if it's not a number or not an integer)
+func (m Message) GetInt(name string) (int64, error) {
+	att, err := m.attribute(name)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if att.kind() != "Number" {
+		return 0, fmt.Errorf("%w: %s is %s", ErrAttributeType, name, att.DataType)
+	}
+
+	num, err := strconv.ParseInt(att.Value, 10, 64)
+
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s is not an integer: %v", ErrAttributeType, name, err)
+	}
+
+	return num, nil
+}
+
+// GetFloat a number attribute as a float
+//
+// returns
+// - the value
+// - any error (sqsc.ErrMissingAttribute if not set, sqsc.ErrAttributeType if it's not a number)
+func (m Message) GetFloat(name string) (float64, error) {
+	att, err := m.attribute(name)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if att.kind() != "Number" {
+		return 0, fmt.Errorf("%w: %s is %s", ErrAttributeType, name, att.DataType)
+	}
+
+	num, err := strconv.ParseFloat(att.Value, 64)
+
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s is not a number: %v", ErrAttributeType, name, err)
+	}
+
+	return num, nil
+}
+
+// GetBytes a binary attribute
+//
+// returns
+// - the value
+// - any error (sqsc.ErrMissingAttribute if not set, sqsc.ErrAttributeType if it's not binary)
+func (m Message) GetBytes(name string) ([]byte, error) {
+	att, err := m.attribute(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if att.kind() != "Binary" {
+		return nil, fmt.Errorf("%w: %s is %s", ErrAttributeType, name, att.DataType)
+	}
+
+	return att.Binary, nil
+}
+
+// attribute look up a typed attribute
+func (m Message) attribute(name string) (Attribute, error) {
+	att, ok := m.TypedAttributes[name]
+
+	if !ok {
+		return Attribute{}, fmt.Errorf("%w: %s", ErrMissingAttribute, name)
+	}
+
+	return att, nil
+}
+
+// kind the data type without any custom suffix
+func (a Attribute) kind() string {
+	return strings.SplitN(a.DataType, ".", 2)[0]
+}
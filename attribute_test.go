@@ -0,0 +1,59 @@
+package sqsc
+
+import (
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"testing"
+)
+
+func TestTypedAttributes(t *testing.T) {
+	c := NewNoop()
+
+	_, err := c.ProduceWithMessageAttributes("body", 0, map[string]*sqs.MessageAttributeValue{
+		"int":    {DataType: aws.String("Number.int"), StringValue: aws.String("42")},
+		"float":  {DataType: aws.String("Number"), StringValue: aws.String("1.5")},
+		"binary": {DataType: aws.String("Binary"), BinaryValue: []byte{1, 2}},
+		"string": {DataType: aws.String("String"), StringValue: aws.String("value")},
+	})
+
+	if err != nil {
+		t.Fatalf("ProduceWithMessageAttributes failed: %v", err)
+	}
+
+	msgs, err := c.ReceiveWithAttributes(1)
+
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("ReceiveWithAttributes failed: %v %+v", err, msgs)
+	}
+
+	msg := msgs[0]
+
+	if n, err := msg.GetInt("int"); n != 42 || err != nil {
+		t.Fatalf("got %d and %v, want 42", n, err)
+	}
+
+	if f, err := msg.GetFloat("float"); f != 1.5 || err != nil {
+		t.Fatalf("got %f and %v, want 1.5", f, err)
+	}
+
+	if b, err := msg.GetBytes("binary"); string(b) != "\x01\x02" || err != nil {
+		t.Fatalf("got %v and %v, want [1 2]", b, err)
+	}
+
+	if s, err := msg.GetString("string"); s != "value" || err != nil {
+		t.Fatalf("got %q and %v, want %q", s, err, "value")
+	}
+
+	if _, err := msg.GetInt("string"); !errors.Is(err, ErrAttributeType) {
+		t.Fatalf("got %v, want %v", err, ErrAttributeType)
+	}
+
+	if _, err := msg.GetString("binary"); !errors.Is(err, ErrAttributeType) {
+		t.Fatalf("got %v, want %v", err, ErrAttributeType)
+	}
+
+	if _, err := msg.GetString("missing"); !errors.Is(err, ErrMissingAttribute) {
+		t.Fatalf("got %v, want %v", err, ErrMissingAttribute)
+	}
+}
@@ -0,0 +1,441 @@
+package sqsc
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"strconv"
+)
+
+const (
+	maxBatchSize  = 10         //<< max entries per batch request
+	maxBatchBytes = 256 * 1024 //<< max total payload per batch request
+)
+
+// ProduceBatch produce many messages on the queue
+//
+// bods - the message bodies
+// del - the delay in seconds (usually just use 0)
+//
+// returns
+// - the message ids (same order as bods, blank if failed)
+// - the per-message errors (same order as bods, nil if succeeded)
+// - any error that failed a whole request (remaining messages are not sent)
+func (c *SQSC) ProduceBatch(bods []string, del int) ([]string, []error, error) {
+	return c.ProduceBatchWithContext(context.Background(), bods, del)
+}
+
+// ProduceBatchWithContext same as ProduceBatch but with a context for cancellation
+func (c *SQSC) ProduceBatchWithContext(ctx context.Context, bods []string, del int) ([]string, []error, error) {
+	res, err := c.ProduceBatchDetailedWithContext(ctx, bods, del)
+
+	ids := make([]string, len(res))
+	errs := make([]error, len(res))
+
+	for i, r := range res {
+		ids[i] = r.ID
+		errs[i] = r.Err
+	}
+
+	return ids, errs, err
+}
+
+// BatchResult the outcome of one message in a batch
+type BatchResult struct {
+	Index int //<< the message's index in the input
+	ProduceResult
+	Err error //<< why it failed (nil if succeeded, in which case the rest is set)
+}
+
+// ProduceBatchDetailed same as ProduceBatch but returns the sequence numbers and md5s along with the message ids
+//
+// returns
+// - the results (same order as bods)
+// - any error that failed a whole request (remaining messages are not sent, and have no id or error)
+func (c *SQSC) ProduceBatchDetailed(bods []string, del int) ([]BatchResult, error) {
+	return c.ProduceBatchDetailedWithContext(context.Background(), bods, del)
+}
+
+// ProduceBatchDetailedWithContext same as ProduceBatchDetailed but with a context for cancellation
+func (c *SQSC) ProduceBatchDetailedWithContext(ctx context.Context, bods []string, del int) ([]BatchResult, error) {
+	out := make([]BatchResult, len(bods))
+
+	for i := range out {
+		out[i].Index = i
+	}
+
+	// fifo queues need a group id
+	if c.fifo() {
+		return out, ErrMissingGroupID
+	}
+
+	if err := delay(del); err != nil {
+		return out, err
+	}
+
+	ents := make([]ProduceEntry, len(bods))
+
+	for i, bod := range bods {
+		ents[i] = ProduceEntry{
+			Body:  bod,
+			Delay: del,
+		}
+	}
+
+	return c.ProduceBatchEntriesWithContext(ctx, ents)
+}
+
+// ProduceEntry a message for ProduceBatchEntries
+type ProduceEntry struct {
+	Body            string                                //<< the message body
+	Delay           int                                   //<< the delay in seconds (0-900, standard queues only)
+	Attributes      map[string]*sqs.MessageAttributeValue //<< the message attributes (optional)
+	GroupID         string                                //<< the message group id (fifo queues only, required)
+	DeduplicationID string                                //<< the deduplication id (fifo queues only, see ProduceFIFO)
+}
+
+// ProduceBatchEntries same as ProduceBatchDetailed but each message has its own delay, attributes, and fifo ids
+//
+// returns
+// - the results (same order as ents, sqsc.ErrMissingGroupID or sqsc.ErrInvalidDelay/ErrDelayTooLong for bad entries)
+// - any error that failed a whole request (remaining messages are not sent, and have no id or error)
+func (c *SQSC) ProduceBatchEntries(ents []ProduceEntry) ([]BatchResult, error) {
+	return c.ProduceBatchEntriesWithContext(context.Background(), ents)
+}
+
+// ProduceBatchEntriesWithContext same as ProduceBatchEntries but with a context for cancellation
+func (c *SQSC) ProduceBatchEntriesWithContext(ctx context.Context, ents []ProduceEntry) ([]BatchResult, error) {
+	out := make([]BatchResult, len(ents))
+
+	for i := range out {
+		out[i].Index = i
+	}
+
+	// build the request entries using the index as the id
+	reqs := make([]*sqs.SendMessageBatchRequestEntry, 0, len(ents))
+
+	for i, ent := range ents {
+		// fifo queues need a group id
+		if c.fifo() && ent.GroupID == "" {
+			out[i].Err = ErrMissingGroupID
+			continue
+		}
+
+		if err := delay(ent.Delay); err != nil {
+			out[i].Err = err
+			continue
+		}
+
+		// compress it if configured
+		bod, attrs, err := c.encode(ent.Body, ent.Attributes)
+
+		if err != nil {
+			out[i].Err = err
+			continue
+		}
+
+		// send large bodies to s3
+		bod, attrs, err = c.offload(ctx, bod, attrs)
+
+		if err != nil {
+			out[i].Err = err
+			continue
+		}
+
+		// too big, don't bother sending it
+		if err := c.fits(bod, attrs); err != nil {
+			out[i].Err = err
+			continue
+		}
+
+		req := &sqs.SendMessageBatchRequestEntry{
+			Id:                aws.String(strconv.Itoa(i)),
+			MessageBody:       aws.String(bod),
+			MessageAttributes: attrs,
+		}
+
+		// fifo queues do not support per-message delays
+		if ent.GroupID == "" {
+			req.DelaySeconds = aws.Int64(int64(ent.Delay))
+		} else {
+			req.MessageGroupId = aws.String(ent.GroupID)
+		}
+
+		// same as content-based dedup, but client side
+		did := ent.DeduplicationID
+
+		if did == "" && ent.GroupID != "" && c.config.HashDedup {
+			did = dedup(ent.Body)
+		}
+
+		if did != "" {
+			req.MessageDeduplicationId = aws.String(did)
+		}
+
+		reqs = append(reqs, req)
+	}
+
+	// send them in chunks
+	for _, chk := range chunk(reqs) {
+		// wait our turn
+		if err := c.throttle(ctx, len(chk)); err != nil {
+			return out, err
+		}
+
+		var res *sqs.SendMessageBatchOutput
+
+		err := c.call(ctx, "ProduceBatch", func(ctx context.Context) (err error) {
+			res, err = c.sqs.SendMessageBatchWithContext(ctx, &sqs.SendMessageBatchInput{
+				QueueUrl: aws.String(c.config.URL),
+				Entries:  chk,
+			})
+
+			return err
+		})
+
+		if err != nil {
+			return out, err
+		}
+
+		c.logf("sqsc: ProduceBatch sent %d of %d messages", len(res.Successful), len(chk))
+
+		// what was actually sent (after compression/offloading) by entry id
+		sent := make(map[string]*sqs.SendMessageBatchRequestEntry, len(chk))
+
+		for _, ent := range chk {
+			sent[aws.StringValue(ent.Id)] = ent
+		}
+
+		for _, ent := range res.Successful {
+			if i, ok := index(ent.Id, len(ents)); ok {
+				// catch corruption in transit
+				if snt := sent[aws.StringValue(ent.Id)]; snt != nil {
+					if err := c.verify(aws.StringValue(ent.MessageId), aws.StringValue(snt.MessageBody), snt.MessageAttributes, ent.MD5OfMessageBody, ent.MD5OfMessageAttributes); err != nil {
+						out[i].Err = err
+						continue
+					}
+				}
+
+				out[i].ProduceResult = ProduceResult{
+					ID:              aws.StringValue(ent.MessageId),
+					SequenceNumber:  aws.StringValue(ent.SequenceNumber),
+					MD5OfBody:       aws.StringValue(ent.MD5OfMessageBody),
+					MD5OfAttributes: aws.StringValue(ent.MD5OfMessageAttributes),
+				}
+			}
+		}
+
+		for _, ent := range res.Failed {
+			if i, ok := index(ent.Id, len(ents)); ok {
+				out[i].Err = batchError("ProduceBatch", ent)
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// chunk split entries into requests that fit the batch limits
+func chunk(ents []*sqs.SendMessageBatchRequestEntry) [][]*sqs.SendMessageBatchRequestEntry {
+	var chks [][]*sqs.SendMessageBatchRequestEntry
+	var chk []*sqs.SendMessageBatchRequestEntry
+
+	siz := 0
+
+	for _, ent := range ents {
+		n := size(aws.StringValue(ent.MessageBody), ent.MessageAttributes)
+
+		// start a new chunk if this one is full
+		if len(chk) == maxBatchSize || (len(chk) != 0 && siz+n > maxBatchBytes) {
+			chks = append(chks, chk)
+			chk = nil
+			siz = 0
+		}
+
+		chk = append(chk, ent)
+		siz += n
+	}
+
+	if len(chk) != 0 {
+		chks = append(chks, chk)
+	}
+
+	return chks
+}
+
+// ranges split n entries into [beg, end) ranges of at most maxBatchSize
+func ranges(n int) [][2]int {
+	var rngs [][2]int
+
+	for beg := 0; beg < n; beg += maxBatchSize {
+		end := beg + maxBatchSize
+
+		if end > n {
+			end = n
+		}
+
+		rngs = append(rngs, [2]int{beg, end})
+	}
+
+	return rngs
+}
+
+// index convert a batch entry id back to its input index
+func index(id *string, max int) (int, bool) {
+	i, err := strconv.Atoi(aws.StringValue(id))
+
+	return i, err == nil && i >= 0 && i < max
+}
+
+// batchError convert a failed batch entry to an error
+func batchError(op string, ent *sqs.BatchResultErrorEntry) error {
+	return wrap(op, awserr.New(aws.StringValue(ent.Code), aws.StringValue(ent.Message), nil))
+}
+
+// DeleteBatch delete many messages from the queue
+//
+// rhs - the receipt handles (from sqsc.Consume())
+//
+// returns
+// - the per-message errors (same order as rhs, nil if succeeded)
+// - any error that failed a whole request (remaining messages are not deleted)
+func (c *SQSC) DeleteBatch(rhs []string) ([]error, error) {
+	return c.DeleteBatchWithContext(context.Background(), rhs)
+}
+
+// DeleteBatchWithContext same as DeleteBatch but with a context for cancellation
+func (c *SQSC) DeleteBatchWithContext(ctx context.Context, rhs []string) ([]error, error) {
+	errs := make([]error, len(rhs))
+
+	// split off the s3 pointers for offloaded bodies
+	ptrs := make([]*s3Pointer, len(rhs))
+
+	// only delete each receipt handle once (duplicates share the first one's result)
+	uniq, dups := distinct(rhs)
+
+	defer func() {
+		for i, j := range dups {
+			errs[i] = errs[j]
+		}
+	}()
+
+	// delete them in chunks using the index as the id
+	for _, rng := range ranges(len(uniq)) {
+		ents := make([]*sqs.DeleteMessageBatchRequestEntry, 0, rng[1]-rng[0])
+
+		for _, i := range uniq[rng[0]:rng[1]] {
+			rh, ptr := unpoint(rhs[i])
+			ptrs[i] = ptr
+
+			ents = append(ents, &sqs.DeleteMessageBatchRequestEntry{
+				Id:            aws.String(strconv.Itoa(i)),
+				ReceiptHandle: aws.String(rh),
+			})
+		}
+
+		var res *sqs.DeleteMessageBatchOutput
+
+		err := c.call(ctx, "DeleteBatch", func(ctx context.Context) (err error) {
+			res, err = c.sqs.DeleteMessageBatchWithContext(ctx, &sqs.DeleteMessageBatchInput{
+				QueueUrl: aws.String(c.config.URL),
+				Entries:  ents,
+			})
+
+			return err
+		})
+
+		if err != nil {
+			return errs, err
+		}
+
+		for _, ent := range res.Failed {
+			if i, ok := index(ent.Id, len(rhs)); ok {
+				errs[i] = batchError("DeleteBatch", ent)
+			}
+		}
+
+		// clean up the offloaded bodies
+		for _, ent := range res.Successful {
+			if i, ok := index(ent.Id, len(rhs)); ok {
+				errs[i] = c.unload(ctx, ptrs[i])
+			}
+		}
+	}
+
+	return errs, nil
+}
+
+// distinct split receipt handles into the indexes of the first of each, and the duplicates (index => first index)
+func distinct(rhs []string) ([]int, map[int]int) {
+	uniq := make([]int, 0, len(rhs))
+	dups := make(map[int]int)
+	seen := make(map[string]int, len(rhs))
+
+	for i, rh := range rhs {
+		if j, ok := seen[rh]; ok {
+			dups[i] = j
+			continue
+		}
+
+		seen[rh] = i
+		uniq = append(uniq, i)
+	}
+
+	return uniq, dups
+}
+
+// ChangeVisibilityBatch change the visibility timeout of many messages
+//
+// rhs - the receipt handles (from sqsc.Consume())
+// sec - the new visibility timeout in seconds (0 to make them visible again now)
+//
+// returns
+// - the per-message errors (same order as rhs, nil if succeeded)
+// - any error that failed a whole request (remaining messages are not changed)
+func (c *SQSC) ChangeVisibilityBatch(rhs []string, sec int) ([]error, error) {
+	return c.ChangeVisibilityBatchWithContext(context.Background(), rhs, sec)
+}
+
+// ChangeVisibilityBatchWithContext same as ChangeVisibilityBatch but with a context for cancellation
+func (c *SQSC) ChangeVisibilityBatchWithContext(ctx context.Context, rhs []string, sec int) ([]error, error) {
+	errs := make([]error, len(rhs))
+
+	// change them in chunks using the index as the id
+	for _, rng := range ranges(len(rhs)) {
+		ents := make([]*sqs.ChangeMessageVisibilityBatchRequestEntry, 0, rng[1]-rng[0])
+
+		for i := rng[0]; i < rng[1]; i++ {
+			rh, _ := unpoint(rhs[i])
+
+			ents = append(ents, &sqs.ChangeMessageVisibilityBatchRequestEntry{
+				Id:                aws.String(strconv.Itoa(i)),
+				ReceiptHandle:     aws.String(rh),
+				VisibilityTimeout: aws.Int64(int64(sec)),
+			})
+		}
+
+		var res *sqs.ChangeMessageVisibilityBatchOutput
+
+		err := c.call(ctx, "ChangeVisibilityBatch", func(ctx context.Context) (err error) {
+			res, err = c.sqs.ChangeMessageVisibilityBatchWithContext(ctx, &sqs.ChangeMessageVisibilityBatchInput{
+				QueueUrl: aws.String(c.config.URL),
+				Entries:  ents,
+			})
+
+			return err
+		})
+
+		if err != nil {
+			return errs, err
+		}
+
+		for _, ent := range res.Failed {
+			if i, ok := index(ent.Id, len(rhs)); ok {
+				errs[i] = batchError("ChangeVisibilityBatch", ent)
+			}
+		}
+	}
+
+	return errs, nil
+}
@@ -0,0 +1,81 @@
+package sqsc
+
+import (
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"testing"
+)
+
+func TestProduceBatchDetailed(t *testing.T) {
+	c, _ := NewWithClient(NewMemory(), &Config{URL: memoryURL, MaxSize: 5})
+
+	res, err := c.ProduceBatchDetailed([]string{"a", "too long", "c"}, 0)
+
+	if err != nil {
+		t.Fatalf("ProduceBatchDetailed failed: %v", err)
+	}
+
+	if len(res) != 3 {
+		t.Fatalf("got %d results, want 3", len(res))
+	}
+
+	for i, r := range res {
+		if r.Index != i {
+			t.Fatalf("result %d has index %d", i, r.Index)
+		}
+	}
+
+	if res[0].ID == "" || res[0].Err != nil || res[0].MD5OfBody != checksum([]byte("a")) {
+		t.Fatalf("expected the first to succeed, got %+v", res[0])
+	}
+
+	if !errors.Is(res[1].Err, ErrMessageTooLarge) || res[1].ID != "" {
+		t.Fatalf("expected the second to be too large, got %+v", res[1])
+	}
+
+	if res[2].ID == "" || res[2].MD5OfBody != checksum([]byte("c")) {
+		t.Fatalf("expected the third to succeed, got %+v", res[2])
+	}
+}
+
+func TestProduceBatchEntries(t *testing.T) {
+	c := NewNoop()
+
+	res, err := c.ProduceBatchEntries([]ProduceEntry{
+		{Body: "delayed", Delay: 5},
+		{Body: "invalid", Delay: -1},
+		{Body: "attributes", Attributes: map[string]*sqs.MessageAttributeValue{
+			"key": {DataType: aws.String("String"), StringValue: aws.String("value")},
+		}},
+	})
+
+	if err != nil {
+		t.Fatalf("ProduceBatchEntries failed: %v", err)
+	}
+
+	if res[0].ID == "" || res[0].Err != nil {
+		t.Fatalf("expected the first to succeed, got %+v", res[0])
+	}
+
+	if !errors.Is(res[1].Err, ErrInvalidDelay) {
+		t.Fatalf("got %v, want %v", res[1].Err, ErrInvalidDelay)
+	}
+
+	if res[2].MD5OfAttributes != attributesChecksum(map[string]*sqs.MessageAttributeValue{
+		"key": {DataType: aws.String("String"), StringValue: aws.String("value")},
+	}) {
+		t.Fatalf("got attributes md5 %q", res[2].MD5OfAttributes)
+	}
+
+	// the delayed one isn't visible yet
+	msgs, _ := c.ReceiveWithAttributes(10)
+
+	if len(msgs) != 1 || msgs[0].Attributes["key"] != "value" {
+		t.Fatalf("got %+v, want only the message with attributes", msgs)
+	}
+
+	if n, _ := c.MessagesDelayed(); n != 1 {
+		t.Fatalf("%d messages delayed, want 1", n)
+	}
+}
@@ -0,0 +1,65 @@
+package sqsc
+
+import (
+	"context"
+	"encoding/base64"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// ProduceBytes produce a new message on the queue with a binary body (i.e. protobuf)
+//
+// the body is base64 encoded (sqs bodies must be valid utf-8) and marked so it's decoded on receive
+//
+// raw - the message body
+// del - the delay in seconds (usually just use 0)
+//
+// returns
+// - the message id
+// - error
+func (c *SQSC) ProduceBytes(raw []byte, del int) (string, error) {
+	return c.ProduceBytesWithContext(context.Background(), raw, del)
+}
+
+// ProduceBytesWithContext same as ProduceBytes but with a context for cancellation
+func (c *SQSC) ProduceBytesWithContext(ctx context.Context, raw []byte, del int) (string, error) {
+	inp := sqs.SendMessageInput{
+		MessageBody:  aws.String(string(raw)),
+		QueueUrl:     aws.String(c.config.URL),
+		DelaySeconds: aws.Int64(int64(del)),
+	}
+
+	// compressed bodies are already base64 encoded
+	if !c.config.Compress {
+		inp.MessageBody = aws.String(base64.StdEncoding.EncodeToString(raw))
+		inp.MessageAttributes = map[string]*sqs.MessageAttributeValue{
+			encodingAttribute: {
+				DataType:    aws.String("String"),
+				StringValue: aws.String("base64"),
+			},
+		}
+	}
+
+	return c.produce(ctx, &inp)
+}
+
+// ConsumeBytes consume a single message from the queue with a binary body (see ProduceBytes)
+//
+// returns
+// - the message body
+// - the receipt handle (use for deleting messages)
+// - any error (sqsc.ErrNoMessages if the queue is empty, or no messages are visible)
+func (c *SQSC) ConsumeBytes() ([]byte, string, error) {
+	return c.ConsumeBytesWithContext(context.Background())
+}
+
+// ConsumeBytesWithContext same as ConsumeBytes but with a context for cancellation
+func (c *SQSC) ConsumeBytesWithContext(ctx context.Context) ([]byte, string, error) {
+	bod, rh, err := c.ConsumeWithContext(ctx)
+
+	if err != nil {
+		return nil, rh, err
+	}
+
+	return []byte(bod), rh, nil
+}
@@ -0,0 +1,80 @@
+package sqsc
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Logger logs what the client is doing (i.e. *log.Logger)
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Metrics records what the client is doing (i.e. prometheus histograms/counters)
+//
+// op is the operation name (i.e. Produce, Receive, Delete, etc)
+type Metrics interface {
+	ObserveLatency(op string, d time.Duration) //<< called after every aws call
+	IncrError(op string)                       //<< called after every failed aws call
+}
+
+// call make an aws call with the operation timeout, logging, metrics, and error wrapping
+func (c *SQSC) call(ctx context.Context, op string, fn func(context.Context) error) error {
+	// so a stalled network can't hang the caller
+	if c.config.OperationTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, c.config.OperationTimeout)
+		defer cancel()
+	}
+
+	// nothing to record, no overhead
+	if c.config.Logger == nil && c.config.Metrics == nil {
+		return c.attempt(ctx, op, fn)
+	}
+
+	beg := time.Now()
+	err := c.attempt(ctx, op, fn)
+	dur := time.Since(beg)
+
+	if c.config.Metrics != nil {
+		c.config.Metrics.ObserveLatency(op, dur)
+
+		if err != nil {
+			c.config.Metrics.IncrError(op)
+		}
+	}
+
+	if err != nil {
+		c.logf("sqsc: %s failed after %s: %v", op, dur, err)
+	} else {
+		c.logf("sqsc: %s took %s", op, dur)
+	}
+
+	return err
+}
+
+// attempt make the call, retrying once with fresh credentials if they expired
+func (c *SQSC) attempt(ctx context.Context, op string, fn func(context.Context) error) error {
+	err := wrap(op, fn(ctx))
+
+	// only if there is something to refresh (and retrying is allowed)
+	if c.creds == nil || c.config.DisableRetries || !errors.Is(err, ErrCredentialsExpired) {
+		return err
+	}
+
+	c.logf("sqsc: %s refreshing expired credentials: %v", op, err)
+
+	// the next call gets new ones from the provider (i.e. assumes the role again)
+	c.creds.Expire()
+
+	return wrap(op, fn(ctx))
+}
+
+// logf log if there is a logger
+func (c *SQSC) logf(format string, v ...interface{}) {
+	if c.config.Logger != nil {
+		c.config.Logger.Printf(format, v...)
+	}
+}
@@ -0,0 +1,221 @@
+package sqsc
+
+import (
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// promMetrics adapts prometheus-style vectors to Metrics, i.e.
+//
+//	latency := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "sqs_seconds"}, []string{"op"})
+//	errors := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "sqs_errors_total"}, []string{"op"})
+//
+//	&promMetrics{
+//		observe: func(op string, sec float64) { latency.WithLabelValues(op).Observe(sec) },
+//		inc:     func(op string) { errors.WithLabelValues(op).Inc() },
+//	}
+type promMetrics struct {
+	observe func(op string, sec float64)
+	inc     func(op string)
+}
+
+// ObserveLatency record the latency in seconds (the prometheus convention)
+func (p *promMetrics) ObserveLatency(op string, d time.Duration) {
+	p.observe(op, d.Seconds())
+}
+
+// IncrError count the failure
+func (p *promMetrics) IncrError(op string) {
+	p.inc(op)
+}
+
+func TestMetrics(t *testing.T) {
+	var mu sync.Mutex
+
+	observed := map[string]int{}
+	failed := map[string]int{}
+
+	c, _ := NewWithClient(NewMemory(), &Config{
+		URL:     memoryURL,
+		Timeout: memoryVisibility,
+		Metrics: &promMetrics{
+			observe: func(op string, sec float64) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if sec < 0 {
+					t.Errorf("negative latency for %s: %f", op, sec)
+				}
+
+				observed[op]++
+			},
+			inc: func(op string) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				failed[op]++
+			},
+		},
+	})
+
+	if _, err := c.Produce("body", 0); err != nil {
+		t.Fatalf("Produce failed: %v", err)
+	}
+
+	msgs, err := c.Receive(1)
+
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("Receive failed: %v %+v", err, msgs)
+	}
+
+	if _, err := c.Delete(msgs[0].ReceiptHandle); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	// already deleted
+	if _, err := c.Delete(msgs[0].ReceiptHandle); err == nil {
+		t.Fatal("expected the second Delete to fail")
+	}
+
+	if observed["Produce"] != 1 || observed["Receive"] != 1 || observed["Delete"] != 2 {
+		t.Fatalf("unexpected latencies observed: %+v", observed)
+	}
+
+	if len(failed) != 1 || failed["Delete"] != 1 {
+		t.Fatalf("unexpected errors counted: %+v", failed)
+	}
+}
+
+// stalledQueue a memory queue whose sends hang until the context is done
+type stalledQueue struct {
+	*Memory
+}
+
+func (q stalledQueue) SendMessageWithContext(ctx aws.Context, _ *sqs.SendMessageInput, _ ...request.Option) (*sqs.SendMessageOutput, error) {
+	<-ctx.Done()
+
+	return nil, awserr.New(request.CanceledErrorCode, "canceled", ctx.Err())
+}
+
+func TestOperationTimeout(t *testing.T) {
+	if _, err := NewWithClient(NewMemory(), &Config{URL: memoryURL, Wait: 2, OperationTimeout: 2 * time.Second}); err != ErrInvalidOperationTimeout {
+		t.Fatalf("got %v, want %v", err, ErrInvalidOperationTimeout)
+	}
+
+	c, _ := NewWithClient(stalledQueue{NewMemory()}, &Config{URL: memoryURL, OperationTimeout: 50 * time.Millisecond})
+
+	beg := time.Now()
+
+	if _, err := c.Produce("body", 0); err == nil {
+		t.Fatal("expected the stalled call to fail")
+	}
+
+	if dur := time.Since(beg); dur > time.Second {
+		t.Fatalf("took %s, want it cut short by the operation timeout", dur)
+	}
+}
+
+// staticProvider counts how many times the credentials were fetched
+type staticProvider struct {
+	retrieved int
+}
+
+func (p *staticProvider) Retrieve() (credentials.Value, error) {
+	p.retrieved++
+
+	return credentials.Value{AccessKeyID: "key", SecretAccessKey: "secret"}, nil
+}
+
+func (p *staticProvider) IsExpired() bool {
+	return false
+}
+
+// expiringQueue a memory queue whose first call fails with expired credentials
+type expiringQueue struct {
+	*Memory
+	calls int
+}
+
+func (q *expiringQueue) SendMessageWithContext(ctx aws.Context, inp *sqs.SendMessageInput, opts ...request.Option) (*sqs.SendMessageOutput, error) {
+	if q.calls++; q.calls == 1 {
+		return nil, awserr.New("ExpiredToken", "expired", nil)
+	}
+
+	return q.Memory.SendMessageWithContext(ctx, inp, opts...)
+}
+
+// the first page succeeds before the credentials expire
+func (q *expiringQueue) ListQueuesPagesWithContext(_ aws.Context, _ *sqs.ListQueuesInput, fn func(*sqs.ListQueuesOutput, bool) bool, _ ...request.Option) error {
+	q.calls++
+
+	fn(&sqs.ListQueuesOutput{QueueUrls: aws.StringSlice([]string{"a"})}, false)
+
+	if q.calls == 1 {
+		return awserr.New("ExpiredTokenException", "expired", nil)
+	}
+
+	fn(&sqs.ListQueuesOutput{QueueUrls: aws.StringSlice([]string{"b"})}, true)
+
+	return nil
+}
+
+func TestExpiredCredentialsRetriedOnce(t *testing.T) {
+	q := &expiringQueue{Memory: NewMemory()}
+	c, _ := NewWithClient(q, &Config{URL: memoryURL})
+
+	// nothing to refresh
+	if _, err := c.Produce("body", 0); !errors.Is(err, ErrCredentialsExpired) {
+		t.Fatalf("got %v, want %v", err, ErrCredentialsExpired)
+	}
+
+	prv := &staticProvider{}
+
+	c.creds = credentials.NewCredentials(prv)
+
+	if _, err := c.creds.Get(); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	q.calls = 0
+
+	if _, err := c.Produce("body", 0); err != nil {
+		t.Fatalf("Produce failed: %v", err)
+	}
+
+	if q.calls != 2 {
+		t.Fatalf("called %d times, want 2", q.calls)
+	}
+
+	// refreshed on the next use
+	if _, err := c.creds.Get(); err != nil || prv.retrieved != 2 {
+		t.Fatalf("fetched the credentials %d times (%v), want 2", prv.retrieved, err)
+	}
+
+	q.calls = 0
+
+	urls, err := c.ListQueues("")
+
+	if err != nil {
+		t.Fatalf("ListQueues failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(urls, []string{"a", "b"}) {
+		t.Fatalf("got %v, want each url once", urls)
+	}
+
+	// the caller does its own retrying
+	c.config.DisableRetries = true
+	q.calls = 0
+
+	if _, err := c.Produce("body", 0); !errors.Is(err, ErrCredentialsExpired) {
+		t.Fatalf("got %v, want %v", err, ErrCredentialsExpired)
+	}
+}
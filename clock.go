@@ -0,0 +1,104 @@
+package sqsc
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock the time source for delays, heartbeats, caches, and the in-memory queue (see Config.Clock)
+type Clock interface {
+	Now() time.Time                         //<< the current time
+	After(d time.Duration) <-chan time.Time //<< a channel that gets the time once d has passed
+}
+
+// systemClock the real clock
+type systemClock struct{}
+
+// Now the current time
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// After wait for the duration
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// FakeClock a clock that only moves when told to (i.e. for deterministic tests)
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+// fakeWaiter a pending After
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewFakeClock creates a fake clock stopped at the given time
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{
+		now: now,
+	}
+}
+
+// Now the fake current time
+func (f *FakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+// After a channel that gets the time once the clock is advanced past d from now
+func (f *FakeClock) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+
+	if d <= 0 {
+		ch <- f.now
+
+		return ch
+	}
+
+	f.waiters = append(f.waiters, fakeWaiter{
+		at: f.now.Add(d),
+		ch: ch,
+	})
+
+	return ch
+}
+
+// Advance move the clock forward, firing any Afters that are due
+func (f *FakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+
+	// keep the ones that aren't due yet
+	waiters := f.waiters[:0]
+
+	for _, w := range f.waiters {
+		if w.at.After(f.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+
+		w.ch <- f.now
+	}
+
+	f.waiters = waiters
+}
+
+// Waiters the number of pending Afters (i.e. to know a heartbeat is waiting before advancing)
+func (f *FakeClock) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.waiters)
+}
@@ -0,0 +1,84 @@
+package sqsc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	clk := NewFakeClock(time.Unix(0, 0))
+
+	now := clk.After(0)
+	later := clk.After(time.Minute)
+
+	select {
+	case <-now:
+	default:
+		t.Fatal("expected a zero After to fire right away")
+	}
+
+	if clk.Waiters() != 1 {
+		t.Fatalf("%d waiters, want 1", clk.Waiters())
+	}
+
+	clk.Advance(time.Minute - time.Second)
+
+	select {
+	case <-later:
+		t.Fatal("fired early")
+	default:
+	}
+
+	clk.Advance(time.Second)
+
+	select {
+	case at := <-later:
+		if !at.Equal(time.Unix(60, 0)) {
+			t.Fatalf("fired at %s, want %s", at, time.Unix(60, 0))
+		}
+	default:
+		t.Fatal("expected it to fire once due")
+	}
+
+	if clk.Waiters() != 0 {
+		t.Fatalf("%d waiters, want 0", clk.Waiters())
+	}
+}
+
+func TestFakeClockDrivesHeartbeats(t *testing.T) {
+	clk := NewFakeClock(time.Unix(0, 0))
+
+	c, _ := NewWithClient(NewMemoryWithClock(clk), &Config{
+		URL:       memoryURL,
+		Timeout:   10,
+		Heartbeat: 5,
+		Clock:     clk,
+	})
+
+	if _, err := c.Produce("body", 0); err != nil {
+		t.Fatalf("Produce failed: %v", err)
+	}
+
+	msgs, _ := c.Receive(1)
+
+	if len(msgs) != 1 {
+		t.Fatalf("got %+v, want 1 message", msgs)
+	}
+
+	stop := c.heartbeat(msgs[0].ReceiptHandle)
+
+	// keep it hidden well past the visibility timeout
+	for i := 0; i < 4; i++ {
+		for clk.Waiters() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		clk.Advance(5 * time.Second)
+	}
+
+	stop()
+
+	if n, _ := c.MessagesNotVisible(); n != 1 {
+		t.Fatalf("%d messages hidden after 20 seconds of heartbeats, want 1", n)
+	}
+}
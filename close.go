@@ -0,0 +1,71 @@
+package sqsc
+
+import "context"
+
+// Close stop streaming/processing and wait for the in-flight handlers to finish
+//
+// new receives stop right away, messages already received are still handled (and deleted if the handler succeeds)
+//
+// if the processing context is cancelled first, the received messages not yet handled are made visible again instead
+//
+// returns
+// - any error (the context's error if it expired before the handlers finished)
+//
+// note: the client can still produce/delete/etc after closing, but can't stream or process again
+func (c *SQSC) Close(ctx context.Context) error {
+	c.mu.Lock()
+
+	select {
+	case <-c.closed:
+	default:
+		close(c.closed)
+	}
+
+	c.mu.Unlock()
+
+	done := make(chan struct{})
+
+	go func() {
+		c.active.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// track count a processor as active so close can wait for it
+//
+// returns false if the client is already closed
+func (c *SQSC) track() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	select {
+	case <-c.closed:
+		return false
+	default:
+		c.active.Add(1)
+
+		return true
+	}
+}
+
+// closable derive a context that is also cancelled when the client is closed
+func (c *SQSC) closable(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		select {
+		case <-c.closed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
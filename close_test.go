@@ -0,0 +1,93 @@
+package sqsc
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCloseHandlesReceivedMessages(t *testing.T) {
+	c := NewNoop()
+
+	if _, _, err := c.ProduceBatch([]string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}, 0); err != nil {
+		t.Fatalf("ProduceBatch failed: %v", err)
+	}
+
+	started := make(chan struct{}, 10)
+	done := make(chan error)
+
+	var handled int32
+
+	go func() {
+		done <- c.Process(context.Background(), func(Message) error {
+			started <- struct{}{}
+
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&handled, 1)
+
+			return nil
+		})
+	}()
+
+	// the whole batch was received by now
+	<-started
+
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if n := atomic.LoadInt32(&handled); n != 10 {
+		t.Fatalf("handled %d messages before Close returned, want 10", n)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Process didn't return after Close")
+	}
+
+	if n, _ := c.MessagesNotVisible(); n != 0 {
+		t.Fatalf("%d messages weren't deleted", n)
+	}
+
+	// closed clients don't process
+	if err := c.Process(context.Background(), func(Message) error { return nil }); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+}
+
+func TestStreamCancelReleasesReceivedMessages(t *testing.T) {
+	c := NewNoop()
+
+	if _, _, err := c.ProduceBatch([]string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}, 0); err != nil {
+		t.Fatalf("ProduceBatch failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	msgs, errs := c.Stream(ctx)
+
+	<-msgs
+
+	cancel()
+
+	// drain them, some of the batch may still be handed out
+	got := 1
+
+	for range msgs {
+		got++
+	}
+
+	for range errs {
+	}
+
+	vis, _ := c.Length()
+
+	if got+vis != 10 {
+		t.Fatalf("got %d messages and %d were made visible again, want 10 in total", got, vis)
+	}
+}
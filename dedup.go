@@ -0,0 +1,141 @@
+package sqsc
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDedupSize = 1000            //<< default max keys remembered by ProduceIdempotent
+	defaultDedupTTL  = 5 * time.Minute //<< default time keys are remembered by ProduceIdempotent
+)
+
+// ProduceIdempotent same as Produce but skips sending if the same key was produced recently
+//
+// key - the idempotency key (blank to use the sha-256 of the body)
+//
+// keys are remembered in memory (per client) for Config.DedupTTL, up to Config.DedupSize keys (least recently used are forgotten first)
+//
+// note: this only guards against double-sends from your own retries, it's not a lock - two concurrent calls with
+// the same key can both send, and other clients/processes don't share the cache (use a fifo queue for real dedup)
+//
+// returns
+// - the message id (the original message's id if skipped)
+// - error
+func (c *SQSC) ProduceIdempotent(key string, bod string, del int) (string, error) {
+	return c.ProduceIdempotentWithContext(context.Background(), key, bod, del)
+}
+
+// ProduceIdempotentWithContext same as ProduceIdempotent but with a context for cancellation
+func (c *SQSC) ProduceIdempotentWithContext(ctx context.Context, key string, bod string, del int) (string, error) {
+	if key == "" {
+		key = dedup(bod)
+	}
+
+	if id, ok := c.sent.get(key); ok {
+		c.logf("sqsc: ProduceIdempotent skipped duplicate of message %s", id)
+
+		return id, nil
+	}
+
+	id, err := c.ProduceWithContext(ctx, bod, del)
+
+	if err != nil {
+		return id, err
+	}
+
+	c.sent.put(key, id)
+
+	return id, nil
+}
+
+// sentCache an lru cache of recently produced message ids with a ttl
+type sentCache struct {
+	mu   sync.Mutex
+	size int
+	ttl  time.Duration
+	clk  Clock
+	lst  *list.List               //<< most recently used at the front
+	keys map[string]*list.Element //<< key => element in the list
+}
+
+// sentEntry a cached message id
+type sentEntry struct {
+	key string
+	id  string
+	exp time.Time
+}
+
+// newSentCache create a cache (defaults for zero values)
+func newSentCache(size int, ttl time.Duration, clk Clock) *sentCache {
+	if size <= 0 {
+		size = defaultDedupSize
+	}
+
+	if ttl <= 0 {
+		ttl = defaultDedupTTL
+	}
+
+	return &sentCache{
+		size: size,
+		ttl:  ttl,
+		clk:  clk,
+		lst:  list.New(),
+		keys: make(map[string]*list.Element),
+	}
+}
+
+// get the message id for a key (if not expired)
+func (s *sentCache) get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elm, ok := s.keys[key]
+
+	if !ok {
+		return "", false
+	}
+
+	ent := elm.Value.(*sentEntry)
+
+	if s.clk.Now().After(ent.exp) {
+		s.lst.Remove(elm)
+		delete(s.keys, key)
+
+		return "", false
+	}
+
+	s.lst.MoveToFront(elm)
+
+	return ent.id, true
+}
+
+// put remember the message id for a key (evicting the least recently used if full)
+func (s *sentCache) put(key string, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ent := &sentEntry{
+		key: key,
+		id:  id,
+		exp: s.clk.Now().Add(s.ttl),
+	}
+
+	if elm, ok := s.keys[key]; ok {
+		elm.Value = ent
+		s.lst.MoveToFront(elm)
+
+		return
+	}
+
+	s.keys[key] = s.lst.PushFront(ent)
+
+	for s.lst.Len() > s.size {
+		elm := s.lst.Back()
+
+		s.lst.Remove(elm)
+		delete(s.keys, elm.Value.(*sentEntry).key)
+	}
+}
@@ -0,0 +1,61 @@
+package sqsc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSentCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	s := newSentCache(2, time.Hour, systemClock{})
+
+	s.put("a", "1")
+	s.put("b", "2")
+	s.get("a")
+	s.put("c", "3")
+
+	if _, ok := s.get("b"); ok {
+		t.Fatal("expected b to be evicted")
+	}
+
+	if id, ok := s.get("a"); !ok || id != "1" {
+		t.Fatalf("got %q, want a to still be cached", id)
+	}
+}
+
+func TestSentCacheExpires(t *testing.T) {
+	clk := NewFakeClock(time.Unix(0, 0))
+	s := newSentCache(2, time.Minute, clk)
+
+	s.put("a", "1")
+	clk.Advance(time.Minute - time.Second)
+
+	if _, ok := s.get("a"); !ok {
+		t.Fatal("expected a to still be cached")
+	}
+
+	clk.Advance(2 * time.Second)
+
+	if _, ok := s.get("a"); ok {
+		t.Fatal("expected a to expire")
+	}
+}
+
+func TestProduceIdempotent(t *testing.T) {
+	c := NewNoop()
+
+	first, err := c.ProduceIdempotent("key", "body", 0)
+
+	if err != nil {
+		t.Fatalf("ProduceIdempotent failed: %v", err)
+	}
+
+	again, err := c.ProduceIdempotent("key", "other body", 0)
+
+	if err != nil || again != first {
+		t.Fatalf("got %q and %v, want the first message id %q", again, err, first)
+	}
+
+	if n, _ := c.Length(); n != 1 {
+		t.Fatalf("%d messages sent, want 1", n)
+	}
+}
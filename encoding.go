@@ -0,0 +1,102 @@
+package sqsc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"io/ioutil"
+)
+
+// encodingAttribute attribute marking an encoded body (always requested so any client can decode it)
+//
+// namespaced so other producers' attributes (i.e. a Content-Encoding of their own) are never mistaken for it
+const encodingAttribute = "sqsc-encoding"
+
+// encode compress the body if configured
+//
+// the gzipped body is base64 encoded since sqs bodies must be valid utf-8
+func (c *SQSC) encode(bod string, attrs map[string]*sqs.MessageAttributeValue) (string, map[string]*sqs.MessageAttributeValue, error) {
+	if !c.config.Compress {
+		return bod, attrs, nil
+	}
+
+	var buf bytes.Buffer
+
+	zw := gzip.NewWriter(&buf)
+
+	if _, err := zw.Write([]byte(bod)); err != nil {
+		return "", nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return "", nil, err
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), with(attrs, encodingAttribute, &sqs.MessageAttributeValue{
+		DataType:    aws.String("String"),
+		StringValue: aws.String("gzip"),
+	}), nil
+}
+
+// decode restore an encoded body (no-op if not encoded, or encoded some way we don't know)
+func decode(msg *sqs.Message) error {
+	enc := msg.MessageAttributes[encodingAttribute]
+
+	if enc == nil {
+		return nil
+	}
+
+	switch aws.StringValue(enc.StringValue) {
+	case "gzip":
+		raw, err := base64.StdEncoding.DecodeString(aws.StringValue(msg.Body))
+
+		if err != nil {
+			return err
+		}
+
+		zr, err := gzip.NewReader(bytes.NewReader(raw))
+
+		if err != nil {
+			return err
+		}
+
+		bod, err := ioutil.ReadAll(zr)
+
+		if err != nil {
+			return err
+		}
+
+		msg.Body = aws.String(string(bod))
+	case "base64":
+		bod, err := base64.StdEncoding.DecodeString(aws.StringValue(msg.Body))
+
+		if err != nil {
+			return err
+		}
+
+		msg.Body = aws.String(string(bod))
+	default:
+		// not ours to undo, hand it over untouched
+		return nil
+	}
+
+	// the body isn't encoded anymore
+	delete(msg.MessageAttributes, encodingAttribute)
+
+	return nil
+}
+
+// with copy the attributes and add one (never mutates the caller's attributes)
+func with(attrs map[string]*sqs.MessageAttributeValue, name string, val *sqs.MessageAttributeValue) map[string]*sqs.MessageAttributeValue {
+	cpy := make(map[string]*sqs.MessageAttributeValue, len(attrs)+1)
+
+	for k, v := range attrs {
+		cpy[k] = v
+	}
+
+	cpy[name] = val
+
+	return cpy
+}
@@ -0,0 +1,80 @@
+package sqsc
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"testing"
+)
+
+func TestCompressRoundTrip(t *testing.T) {
+	c := &SQSC{config: Config{Compress: true}}
+
+	bod, attrs, err := c.encode("hello hello hello", nil)
+
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	if bod == "hello hello hello" || aws.StringValue(attrs[encodingAttribute].StringValue) != "gzip" {
+		t.Fatalf("expected a gzipped body, got %q and %+v", bod, attrs)
+	}
+
+	msg := &sqs.Message{
+		Body:              aws.String(bod),
+		MessageAttributes: attrs,
+	}
+
+	if err := decode(msg); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+
+	if got := aws.StringValue(msg.Body); got != "hello hello hello" {
+		t.Fatalf("got body %q, want the original", got)
+	}
+
+	if len(msg.MessageAttributes) != 0 {
+		t.Fatalf("expected the encoding attribute to be removed, got %+v", msg.MessageAttributes)
+	}
+}
+
+func TestDecodeUnknownEncoding(t *testing.T) {
+	for _, attrs := range []map[string]*sqs.MessageAttributeValue{
+		{"Content-Encoding": {DataType: aws.String("String"), StringValue: aws.String("identity")}},
+		{encodingAttribute: {DataType: aws.String("String"), StringValue: aws.String("br")}},
+	} {
+		msg := &sqs.Message{
+			Body:              aws.String("body"),
+			MessageAttributes: attrs,
+		}
+
+		if err := decode(msg); err != nil {
+			t.Fatalf("decode failed: %v", err)
+		}
+
+		if got := aws.StringValue(msg.Body); got != "body" || len(msg.MessageAttributes) != 1 {
+			t.Fatalf("expected the message untouched, got %q and %+v", got, msg.MessageAttributes)
+		}
+	}
+}
+
+func TestBytesRoundTrip(t *testing.T) {
+	raw := []byte{0xff, 0x00, 0xfe, 'a'}
+
+	for _, cmp := range []bool{false, true} {
+		c, _ := NewWithClient(NewMemory(), &Config{URL: memoryURL, Compress: cmp})
+
+		if _, err := c.ProduceBytes(raw, 0); err != nil {
+			t.Fatalf("ProduceBytes failed: %v", err)
+		}
+
+		msgs, err := c.Receive(1)
+
+		if err != nil || len(msgs) != 1 {
+			t.Fatalf("Receive failed: %v %+v", err, msgs)
+		}
+
+		if msgs[0].Body != string(raw) {
+			t.Fatalf("compress %t: got body %q, want %q", cmp, msgs[0].Body, raw)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package sqsc
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+var (
+	// ErrMissingRegion returned by New when the region is blank
+	ErrMissingRegion = errors.New("region must be set")
+
+	// ErrMissingQueue returned by New and NewWithClient when neither the queue name nor url is set (or no name to create)
+	ErrMissingQueue = errors.New("either Queue or URL must be set")
+
+	// ErrMissingGroupID returned when producing to a fifo queue without a message group id
+	ErrMissingGroupID = errors.New("message group id is required for fifo queues")
+
+	// ErrQueueNotFound matches the error from New or any operation when the queue does not exist
+	ErrQueueNotFound = errors.New("queue not found")
+
+	// ErrQueueDeletedRecently matches the error from CreateQueue when a queue with the same name was deleted in the last 60 seconds
+	ErrQueueDeletedRecently = errors.New("queue deleted recently (wait 60 seconds before recreating it)")
+
+	// ErrNoMessages returned by Consume and ConsumeMessage when the queue is empty, or no messages are visible
+	ErrNoMessages = errors.New("no messages")
+
+	// ErrMissingReceiptHandle returned when deleting a nil message or one without a receipt handle
+	ErrMissingReceiptHandle = errors.New("message has no receipt handle")
+
+	// ErrMalformedMessage a received message with no body or receipt handle (see PartialError)
+	ErrMalformedMessage = errors.New("message has no body or receipt handle")
+
+	// ErrPurgeInProgress matches the error from Purge when the queue was already purged in the last 60 seconds
+	ErrPurgeInProgress = errors.New("purge already in progress (only one purge allowed every 60 seconds)")
+
+	// ErrInvalidBatchSize returned when receiving with a max number of messages outside 1-10
+	ErrInvalidBatchSize = errors.New("max number of messages must be 1-10")
+
+	// ErrInvalidTimeout returned when a visibility timeout is outside 0-43200 seconds
+	ErrInvalidTimeout = errors.New("visibility timeout must be 0-43200 seconds")
+
+	// ErrInvalidWait returned when a wait time is outside 0-20 seconds
+	ErrInvalidWait = errors.New("wait time must be 0-20 seconds")
+
+	// ErrInvalidRequestTimeout returned by New when the request timeout is not longer than the wait time
+	ErrInvalidRequestTimeout = errors.New("request timeout must be longer than the wait time")
+
+	// ErrInvalidOperationTimeout returned by New when the operation timeout is not longer than the wait time
+	ErrInvalidOperationTimeout = errors.New("operation timeout must be longer than the wait time")
+
+	// ErrInvalidBuffer returned by New when the stream buffer size is negative
+	ErrInvalidBuffer = errors.New("buffer size cannot be negative")
+
+	// ErrInvalidBackoff returned by New when the empty receive backoff or its max is negative
+	ErrInvalidBackoff = errors.New("empty receive backoff cannot be negative")
+
+	// ErrInvalidDelay returned when producing with a negative delay
+	ErrInvalidDelay = errors.New("delay cannot be negative")
+
+	// ErrDelayTooLong returned when producing with a delay over 900 seconds (15 minutes)
+	ErrDelayTooLong = errors.New("delay cannot be over 900 seconds (15 minutes)")
+
+	// ErrMessageTooLarge returned (wrapped with the actual size) when a message is over the max size
+	ErrMessageTooLarge = errors.New("message too large")
+
+	// ErrChecksumMismatch returned (wrapped with the message id) when Config.VerifyMD5 is set and a body or attributes md5 doesn't match
+	// (also matches the sdk's own body checksum errors)
+	ErrChecksumMismatch = errors.New("md5 checksum mismatch")
+
+	// ErrEmptyAttributeName returned when setting an attribute with a blank name
+	ErrEmptyAttributeName = errors.New("attribute name cannot be blank")
+
+	// ErrInvalidMaxReceiveCount returned when setting a redrive policy with a max receive count outside 1-1000
+	ErrInvalidMaxReceiveCount = errors.New("max receive count must be 1-1000")
+
+	// ErrMissingAttribute returned (wrapped with the name) when getting a message attribute that wasn't received, or a queue count aws didn't return
+	ErrMissingAttribute = errors.New("attribute not found")
+
+	// ErrAttributeType returned (wrapped with the name) when getting a message attribute as the wrong data type
+	ErrAttributeType = errors.New("attribute has a different data type")
+
+	// ErrInvalidPolicy returned (wrapped with why) when setting a queue policy with a statement missing its effect or action
+	ErrInvalidPolicy = errors.New("invalid policy")
+
+	// ErrEmptyTagKey returned when tagging/untagging with a blank key
+	ErrEmptyTagKey = errors.New("tag key cannot be blank")
+
+	// ErrMissingCredentials returned by NewWithOptions when static credentials are missing the key or secret
+	ErrMissingCredentials = errors.New("static credentials need both a key and a secret")
+
+	// ErrConflictingOptions returned (wrapped with which ones) by NewWithOptions when two options can't be used together
+	ErrConflictingOptions = errors.New("conflicting options")
+
+	// ErrCredentialsExpired matches the error from any operation when the credentials expired (after refreshing them and retrying once)
+	ErrCredentialsExpired = errors.New("credentials expired")
+
+	// ErrUnknownQueue returned by MultiConsumer.Delete when the message didn't come from one of its queues
+	ErrUnknownQueue = errors.New("message is not from any of the queues")
+
+	// ErrNotHandled set by ProcessBatch on the messages the batch handler didn't return an error for (its slice was short)
+	ErrNotHandled = errors.New("handler returned no result for the message")
+)
+
+const (
+	expiredTokenCode          = "ExpiredToken"          //<< the aws error code for expired credentials
+	expiredTokenExceptionCode = "ExpiredTokenException" //<< same as above, from some services
+)
+
+// Error an error from an aws call
+//
+// use errors.As to get at the code, or errors.Is to match the sentinel errors above
+type Error struct {
+	Op      string //<< the operation (i.e. Produce, Receive, etc)
+	Code    string //<< the aws error code (blank if not an aws error)
+	Message string //<< the aws error message
+	err     error
+}
+
+// Error the error string
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("sqsc: %s: %s", e.Op, e.Message)
+	}
+
+	return fmt.Sprintf("sqsc: %s: %s: %s", e.Op, e.Code, e.Message)
+}
+
+// Unwrap the underlying error
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Is match the sentinel errors by aws error code
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrQueueNotFound:
+		return e.Code == sqs.ErrCodeQueueDoesNotExist
+	case ErrQueueDeletedRecently:
+		return e.Code == sqs.ErrCodeQueueDeletedRecently
+	case ErrPurgeInProgress:
+		return e.Code == sqs.ErrCodePurgeQueueInProgress
+	case ErrChecksumMismatch:
+		return e.Code == checksumCode
+	case ErrCredentialsExpired:
+		return e.Code == expiredTokenCode || e.Code == expiredTokenExceptionCode
+	}
+
+	return false
+}
+
+// MessageError a received message that couldn't be handed back
+type MessageError struct {
+	Index int    //<< the message's index in the aws response
+	ID    string //<< the message id (blank if aws didn't send one)
+	Err   error  //<< why (i.e. sqsc.ErrMalformedMessage, sqsc.ErrChecksumMismatch, a decode or s3 error)
+}
+
+// PartialError returned (with the good messages) when some received messages were skipped
+//
+// the skipped messages are redelivered after the visibility timeout
+type PartialError struct {
+	Received int            //<< how many messages aws sent
+	Failed   []MessageError //<< the skipped messages
+}
+
+// Error the error string
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("sqsc: Receive: skipped %d of %d messages: %v", len(e.Failed), e.Received, e.Failed[0].Err)
+}
+
+// Unwrap the first skipped message's error
+func (e *PartialError) Unwrap() error {
+	return e.Failed[0].Err
+}
+
+// wrap wrap an aws error with the operation (nil if no error)
+func wrap(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	// already wrapped
+	var e *Error
+
+	if errors.As(err, &e) {
+		return err
+	}
+
+	var aer awserr.Error
+
+	if errors.As(err, &aer) {
+		return &Error{
+			Op:      op,
+			Code:    aer.Code(),
+			Message: aer.Message(),
+			err:     err,
+		}
+	}
+
+	return &Error{
+		Op:      op,
+		Message: err.Error(),
+		err:     err,
+	}
+}
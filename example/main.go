@@ -14,6 +14,7 @@ func main() {
 	id := flag.String("id", "", "aws account id")
 	key := flag.String("key", "", "aws account secret")
 	sec := flag.String("secret", "", "aws account secret")
+	anon := flag.Bool("anonymous", false, "use anonymous credentials")
 	q := flag.String("queue", "", "queue name")
 	ret := flag.Int("retries", 0, "max retries")
 	to := flag.Int("timeout", 0, "timeout")
@@ -24,16 +25,17 @@ func main() {
 	fmt.Printf("body: %+v\n", *bod)
 
 	cfg := sqsc.Config{
-		Region:   *reg,
-		URL:      *url,
-		Endpoint: *ep,
-		ID:       *id,
-		Key:      *key,
-		Secret:   *sec,
-		Queue:    *q,
-		Retries:  *ret,
-		Timeout:  *to,
-		Wait:     *wt,
+		Region:    *reg,
+		URL:       *url,
+		Endpoint:  *ep,
+		ID:        *id,
+		Key:       *key,
+		Secret:    *sec,
+		Anonymous: *anon,
+		Queue:     *q,
+		Retries:   *ret,
+		Timeout:   *to,
+		Wait:      *wt,
 	}
 
 	cli, err := sqsc.New(&cfg)
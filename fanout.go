@@ -0,0 +1,60 @@
+package sqsc
+
+import (
+	"context"
+	"sync"
+)
+
+// FanOut produces the same messages to many queues
+type FanOut struct {
+	clis []*SQSC
+}
+
+// NewFanOut creates a new fan out over the given clients
+func NewFanOut(clis ...*SQSC) *FanOut {
+	return &FanOut{
+		clis: clis,
+	}
+}
+
+// Produce produce a new message on every queue concurrently
+//
+// bod - the message body
+// del - the delay in seconds (usually just use 0)
+//
+// returns
+// - the message ids (queue url => message id, only for the queues that succeeded)
+// - the per-queue errors (same order as the clients, nil if succeeded)
+func (f *FanOut) Produce(bod string, del int) (map[string]string, []error) {
+	return f.ProduceWithContext(context.Background(), bod, del)
+}
+
+// ProduceWithContext same as Produce but with a context for cancellation
+func (f *FanOut) ProduceWithContext(ctx context.Context, bod string, del int) (map[string]string, []error) {
+	ids := make([]string, len(f.clis))
+	errs := make([]error, len(f.clis))
+
+	var wg sync.WaitGroup
+
+	for i, cli := range f.clis {
+		wg.Add(1)
+
+		go func(i int, cli *SQSC) {
+			defer wg.Done()
+
+			ids[i], errs[i] = cli.ProduceWithContext(ctx, bod, del)
+		}(i, cli)
+	}
+
+	wg.Wait()
+
+	res := make(map[string]string, len(f.clis))
+
+	for i, cli := range f.clis {
+		if errs[i] == nil {
+			res[cli.URL()] = ids[i]
+		}
+	}
+
+	return res, errs
+}
@@ -1,5 +1,10 @@
 module github.com/chaseisabelle/sqsc
 
-go 1.13
+go 1.18
 
-require github.com/aws/aws-sdk-go v1.34.0
+require (
+	github.com/aws/aws-sdk-go v1.34.0
+	golang.org/x/time v0.0.0-20200630173020-3af7569d3a1e
+)
+
+require github.com/jmespath/go-jmespath v0.3.0 // indirect
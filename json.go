@@ -0,0 +1,90 @@
+package sqsc
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// ProduceJSON produce a new message on the queue with v marshalled to json as the body
+//
+// v - the value to marshal
+// del - the delay in seconds (usually just use 0)
+//
+// returns
+// - the message id
+// - error
+func (c *SQSC) ProduceJSON(v interface{}, del int) (string, error) {
+	return c.ProduceJSONWithContext(context.Background(), v, del)
+}
+
+// ProduceJSONWithContext same as ProduceJSON but with a context for cancellation
+func (c *SQSC) ProduceJSONWithContext(ctx context.Context, v interface{}, del int) (string, error) {
+	bod, err := json.Marshal(v)
+
+	if err != nil {
+		return "", err
+	}
+
+	return c.ProduceWithContext(ctx, string(bod), del)
+}
+
+// ConsumeJSON consume a single message from the queue and unmarshal its json body into v
+//
+// v - pointer to unmarshal into (same as json.Unmarshal)
+//
+// returns
+// - the receipt handle (use for deleting messages, even if unmarshalling failed)
+// - any error (sqsc.ErrNoMessages if the queue is empty, or no messages are visible)
+func (c *SQSC) ConsumeJSON(v interface{}) (string, error) {
+	return c.ConsumeJSONWithContext(context.Background(), v)
+}
+
+// ConsumeJSONWithContext same as ConsumeJSON but with a context for cancellation
+func (c *SQSC) ConsumeJSONWithContext(ctx context.Context, v interface{}) (string, error) {
+	bod, rh, err := c.ConsumeWithContext(ctx)
+
+	if err != nil {
+		return rh, err
+	}
+
+	return rh, json.Unmarshal([]byte(bod), v)
+}
+
+// ProduceJSON produce a new message on the queue with v marshalled to json as the body (generic form of SQSC.ProduceJSON)
+//
+// c - the client to produce with
+// v - the value to marshal
+// del - the delay in seconds (usually just use 0)
+//
+// returns
+// - the message id
+// - error
+func ProduceJSON[T any](c *SQSC, v T, del int) (string, error) {
+	return ProduceJSONWithContext(context.Background(), c, v, del)
+}
+
+// ProduceJSONWithContext same as ProduceJSON but with a context for cancellation
+func ProduceJSONWithContext[T any](ctx context.Context, c *SQSC, v T, del int) (string, error) {
+	return c.ProduceJSONWithContext(ctx, v, del)
+}
+
+// ConsumeJSON consume a single message from the queue and unmarshal its json body into a T (generic form of SQSC.ConsumeJSON)
+//
+// c - the client to consume with
+//
+// returns
+// - the unmarshalled value (the zero value if there was no message)
+// - the receipt handle (use for deleting messages, even if unmarshalling failed)
+// - any error (sqsc.ErrNoMessages if the queue is empty, or no messages are visible)
+func ConsumeJSON[T any](c *SQSC) (T, string, error) {
+	return ConsumeJSONWithContext[T](context.Background(), c)
+}
+
+// ConsumeJSONWithContext same as ConsumeJSON but with a context for cancellation
+func ConsumeJSONWithContext[T any](ctx context.Context, c *SQSC) (T, string, error) {
+	var v T
+
+	rh, err := c.ConsumeJSONWithContext(ctx, &v)
+
+	return v, rh, err
+}
@@ -0,0 +1,41 @@
+package sqsc
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGenericJSON(t *testing.T) {
+	type thing struct {
+		N int `json:"n"`
+	}
+
+	c := NewNoop()
+
+	if v, _, err := ConsumeJSON[thing](c); !errors.Is(err, ErrNoMessages) || v != (thing{}) {
+		t.Fatalf("got %+v and %v, want the zero value and %v", v, err, ErrNoMessages)
+	}
+
+	if _, err := ProduceJSON(c, thing{N: 1}, 0); err != nil {
+		t.Fatalf("ProduceJSON failed: %v", err)
+	}
+
+	v, rh, err := ConsumeJSON[thing](c)
+
+	if err != nil || v.N != 1 {
+		t.Fatalf("got %+v and %v, want the produced json", v, err)
+	}
+
+	if _, err := c.Delete(rh); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	// still handed the receipt handle to delete it
+	if _, err := c.Produce("not json", 0); err != nil {
+		t.Fatalf("Produce failed: %v", err)
+	}
+
+	if _, rh, err := ConsumeJSON[thing](c); err == nil || rh == "" {
+		t.Fatalf("got %q and %v, want an unmarshalling error with the handle", rh, err)
+	}
+}
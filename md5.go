@@ -0,0 +1,79 @@
+package sqsc
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"sort"
+)
+
+// checksumCode the aws error code for a checksum mismatch caught by the sdk
+const checksumCode = "InvalidChecksum"
+
+// verify compare the md5s aws returned with the body and attributes (no-op unless Config.VerifyMD5)
+func (c *SQSC) verify(id string, bod string, attrs map[string]*sqs.MessageAttributeValue, bmd *string, amd *string) error {
+	if !c.config.VerifyMD5 {
+		return nil
+	}
+
+	if aws.StringValue(bmd) != checksum([]byte(bod)) {
+		return fmt.Errorf("%w: message %s body", ErrChecksumMismatch, id)
+	}
+
+	if len(attrs) != 0 && aws.StringValue(amd) != attributesChecksum(attrs) {
+		return fmt.Errorf("%w: message %s attributes", ErrChecksumMismatch, id)
+	}
+
+	return nil
+}
+
+// checksum the hex md5
+func checksum(raw []byte) string {
+	sum := md5.Sum(raw)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// attributesChecksum the hex md5 of the message attributes the same way aws does it
+//
+// sorted by name, each as the length prefixed name, data type, transport type (1 string, 2 binary), and value
+func attributesChecksum(attrs map[string]*sqs.MessageAttributeValue) string {
+	names := make([]string, 0, len(attrs))
+
+	for k := range attrs {
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
+
+	var raw []byte
+
+	field := func(b []byte) {
+		var n [4]byte
+
+		binary.BigEndian.PutUint32(n[:], uint32(len(b)))
+
+		raw = append(raw, n[:]...)
+		raw = append(raw, b...)
+	}
+
+	for _, k := range names {
+		v := attrs[k]
+
+		field([]byte(k))
+		field([]byte(aws.StringValue(v.DataType)))
+
+		if v.StringValue != nil {
+			raw = append(raw, 1)
+			field([]byte(*v.StringValue))
+		} else {
+			raw = append(raw, 2)
+			field(v.BinaryValue)
+		}
+	}
+
+	return checksum(raw)
+}
@@ -0,0 +1,61 @@
+package sqsc
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"testing"
+)
+
+// corruptingQueue a memory queue that returns the wrong body md5
+type corruptingQueue struct {
+	*Memory
+}
+
+func (q corruptingQueue) SendMessageWithContext(ctx aws.Context, inp *sqs.SendMessageInput, opts ...request.Option) (*sqs.SendMessageOutput, error) {
+	res, err := q.Memory.SendMessageWithContext(ctx, inp, opts...)
+
+	if err == nil {
+		res.MD5OfMessageBody = aws.String("corrupt")
+	}
+
+	return res, err
+}
+
+func TestAttributesChecksum(t *testing.T) {
+	// the length prefixed name, data type, transport type (string), and value
+	raw := []byte("\x00\x00\x00\x01a\x00\x00\x00\x06String\x01\x00\x00\x00\x01b")
+	sum := md5.Sum(raw)
+
+	got := attributesChecksum(map[string]*sqs.MessageAttributeValue{
+		"a": {DataType: aws.String("String"), StringValue: aws.String("b")},
+	})
+
+	if want := hex.EncodeToString(sum[:]); got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestVerifyMD5(t *testing.T) {
+	c, _ := NewWithClient(NewMemory(), &Config{URL: memoryURL, VerifyMD5: true, Compress: true})
+
+	if _, err := c.ProduceWithAttributes("body", 0, map[string]string{"key": "value"}); err != nil {
+		t.Fatalf("ProduceWithAttributes failed: %v", err)
+	}
+
+	c, _ = NewWithClient(corruptingQueue{NewMemory()}, &Config{URL: memoryURL, VerifyMD5: true})
+
+	if _, err := c.Produce("body", 0); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("got %v, want %v", err, ErrChecksumMismatch)
+	}
+
+	// not checked unless configured
+	c, _ = NewWithClient(corruptingQueue{NewMemory()}, &Config{URL: memoryURL})
+
+	if _, err := c.Produce("body", 0); err != nil {
+		t.Fatalf("Produce failed: %v", err)
+	}
+}
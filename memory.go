@@ -0,0 +1,733 @@
+package sqsc
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	memoryName       = "sqsc"                   //<< the queue name of a noop client
+	memoryURL        = "memory://" + memoryName //<< the queue url of a noop client
+	memoryPoll       = 10 * time.Millisecond    //<< how often a long poll checks the in-memory queue
+	memoryVisibility = 30                       //<< the default visibility timeout (seconds, same as aws)
+)
+
+// NewNoop creates a client backed by an in-memory queue (i.e. for examples and tests)
+//
+// nothing is sent to aws, produced messages can be received, deleted, etc (see Memory)
+//
+// note: received messages are hidden for 30 seconds (the aws default visibility timeout)
+func NewNoop() *SQSC {
+	// can't fail, the url is set so nothing is looked up
+	c, _ := NewWithClient(NewMemory(), &Config{
+		URL:     memoryURL,
+		Timeout: memoryVisibility,
+	})
+
+	return c
+}
+
+// Memory an in-memory queue that stands in for the sqs client (use with NewWithClient)
+//
+// honors the delay and visibility timeout (received messages are redelivered after it unless
+// deleted) and is safe for many consumers
+//
+// note: it's a single queue (the queue url is ignored) and some things aren't supported
+// - fifo group and deduplication ids are ignored (messages are never deduplicated or ordered by group)
+// - queue attributes are stored as is (i.e. a redrive policy never moves messages to a dead letter queue)
+// - every receive returns all the message and system attributes, whichever names were asked for
+// - sns topics can be subscribed to, but nothing is ever delivered from them
+// - permissions, dead letter source queues, and the sdk's *Request forms fail with an UnsupportedOperation error
+type Memory struct {
+	mu    sync.Mutex
+	clk   Clock
+	msgs  []*memoryMessage
+	seq   int
+	attrs map[string]string //<< queue attributes set by creating the queue or setting them
+	tags  map[string]string
+}
+
+// memoryMessage a message in the in-memory queue
+type memoryMessage struct {
+	id       string
+	body     string
+	attrs    map[string]*sqs.MessageAttributeValue
+	handle   string    //<< receipt handle of the last receive (blank if never received)
+	visible  time.Time //<< hidden until then (delayed or received)
+	receives int
+	sent     time.Time
+}
+
+// NewMemory creates an empty in-memory queue
+func NewMemory() *Memory {
+	return NewMemoryWithClock(systemClock{})
+}
+
+// NewMemoryWithClock same as NewMemory but delays, visibility timeouts, and long polls use the given clock
+//
+// clk - the clock (i.e. a *FakeClock to redeliver messages without waiting out the timeout)
+func NewMemoryWithClock(clk Clock) *Memory {
+	return &Memory{
+		clk:   clk,
+		attrs: map[string]string{},
+		tags:  map[string]string{},
+	}
+}
+
+// SendMessageWithContext add a message to the queue
+func (m *Memory) SendMessageWithContext(_ aws.Context, inp *sqs.SendMessageInput, _ ...request.Option) (*sqs.SendMessageOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	msg := m.add(aws.StringValue(inp.MessageBody), inp.MessageAttributes, aws.Int64Value(inp.DelaySeconds))
+
+	return &sqs.SendMessageOutput{
+		MessageId:              aws.String(msg.id),
+		MD5OfMessageBody:       aws.String(checksum([]byte(msg.body))),
+		MD5OfMessageAttributes: attributesMD5(msg.attrs),
+	}, nil
+}
+
+// SendMessageBatchWithContext add many messages to the queue
+func (m *Memory) SendMessageBatchWithContext(_ aws.Context, inp *sqs.SendMessageBatchInput, _ ...request.Option) (*sqs.SendMessageBatchOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	res := &sqs.SendMessageBatchOutput{}
+
+	for _, ent := range inp.Entries {
+		msg := m.add(aws.StringValue(ent.MessageBody), ent.MessageAttributes, aws.Int64Value(ent.DelaySeconds))
+
+		res.Successful = append(res.Successful, &sqs.SendMessageBatchResultEntry{
+			Id:                     ent.Id,
+			MessageId:              aws.String(msg.id),
+			MD5OfMessageBody:       aws.String(checksum([]byte(msg.body))),
+			MD5OfMessageAttributes: attributesMD5(msg.attrs),
+		})
+	}
+
+	return res, nil
+}
+
+// ReceiveMessageWithContext hide and return the visible messages (waiting up to the wait time for some)
+func (m *Memory) ReceiveMessageWithContext(ctx aws.Context, inp *sqs.ReceiveMessageInput, _ ...request.Option) (*sqs.ReceiveMessageOutput, error) {
+	wait := time.Duration(aws.Int64Value(inp.WaitTimeSeconds)) * time.Second
+
+	// the queue's default if not given
+	vis := int64(memoryVisibility)
+
+	if inp.VisibilityTimeout != nil {
+		vis = *inp.VisibilityTimeout
+	}
+
+	for {
+		if msgs := m.take(int(aws.Int64Value(inp.MaxNumberOfMessages)), vis); len(msgs) != 0 || wait <= 0 {
+			return &sqs.ReceiveMessageOutput{
+				Messages: msgs,
+			}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-m.clk.After(memoryPoll):
+			wait -= memoryPoll
+		}
+	}
+}
+
+// DeleteMessageWithContext remove a received message from the queue
+func (m *Memory) DeleteMessageWithContext(_ aws.Context, inp *sqs.DeleteMessageInput, _ ...request.Option) (*sqs.DeleteMessageOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.remove(aws.StringValue(inp.ReceiptHandle)); err != nil {
+		return nil, err
+	}
+
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+// DeleteMessageBatchWithContext remove many received messages from the queue
+func (m *Memory) DeleteMessageBatchWithContext(_ aws.Context, inp *sqs.DeleteMessageBatchInput, _ ...request.Option) (*sqs.DeleteMessageBatchOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	res := &sqs.DeleteMessageBatchOutput{}
+
+	for _, ent := range inp.Entries {
+		if err := m.remove(aws.StringValue(ent.ReceiptHandle)); err != nil {
+			res.Failed = append(res.Failed, memoryFailure(ent.Id, err))
+			continue
+		}
+
+		res.Successful = append(res.Successful, &sqs.DeleteMessageBatchResultEntry{
+			Id: ent.Id,
+		})
+	}
+
+	return res, nil
+}
+
+// ChangeMessageVisibilityWithContext hide a received message for the timeout from now (0 to make it visible again now)
+func (m *Memory) ChangeMessageVisibilityWithContext(_ aws.Context, inp *sqs.ChangeMessageVisibilityInput, _ ...request.Option) (*sqs.ChangeMessageVisibilityOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.show(aws.StringValue(inp.ReceiptHandle), aws.Int64Value(inp.VisibilityTimeout)); err != nil {
+		return nil, err
+	}
+
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+// ChangeMessageVisibilityBatchWithContext same as ChangeMessageVisibilityWithContext but for many messages
+func (m *Memory) ChangeMessageVisibilityBatchWithContext(_ aws.Context, inp *sqs.ChangeMessageVisibilityBatchInput, _ ...request.Option) (*sqs.ChangeMessageVisibilityBatchOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	res := &sqs.ChangeMessageVisibilityBatchOutput{}
+
+	for _, ent := range inp.Entries {
+		if err := m.show(aws.StringValue(ent.ReceiptHandle), aws.Int64Value(ent.VisibilityTimeout)); err != nil {
+			res.Failed = append(res.Failed, memoryFailure(ent.Id, err))
+			continue
+		}
+
+		res.Successful = append(res.Successful, &sqs.ChangeMessageVisibilityBatchResultEntry{
+			Id: ent.Id,
+		})
+	}
+
+	return res, nil
+}
+
+// GetQueueUrlWithContext the url for the queue name (any name resolves to the one queue)
+func (m *Memory) GetQueueUrlWithContext(_ aws.Context, inp *sqs.GetQueueUrlInput, _ ...request.Option) (*sqs.GetQueueUrlOutput, error) {
+	return &sqs.GetQueueUrlOutput{
+		QueueUrl: aws.String(memoryURL + "/" + aws.StringValue(inp.QueueName)),
+	}, nil
+}
+
+// PurgeQueueWithContext remove every message from the queue
+func (m *Memory) PurgeQueueWithContext(_ aws.Context, _ *sqs.PurgeQueueInput, _ ...request.Option) (*sqs.PurgeQueueOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.msgs = nil
+
+	return &sqs.PurgeQueueOutput{}, nil
+}
+
+// CreateQueueWithContext set the attributes and return the url for the queue name (there's always the one queue)
+func (m *Memory) CreateQueueWithContext(ctx aws.Context, inp *sqs.CreateQueueInput, opts ...request.Option) (*sqs.CreateQueueOutput, error) {
+	m.mu.Lock()
+
+	for k, v := range inp.Attributes {
+		m.attrs[k] = aws.StringValue(v)
+	}
+
+	for k, v := range inp.Tags {
+		m.tags[k] = aws.StringValue(v)
+	}
+
+	m.mu.Unlock()
+
+	url, err := m.GetQueueUrlWithContext(ctx, &sqs.GetQueueUrlInput{QueueName: inp.QueueName}, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &sqs.CreateQueueOutput{
+		QueueUrl: url.QueueUrl,
+	}, nil
+}
+
+// DeleteQueueWithContext remove every message, attribute, and tag (the queue is empty, like a newly created one)
+func (m *Memory) DeleteQueueWithContext(_ aws.Context, _ *sqs.DeleteQueueInput, _ ...request.Option) (*sqs.DeleteQueueOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.msgs = nil
+	m.attrs = map[string]string{}
+	m.tags = map[string]string{}
+
+	return &sqs.DeleteQueueOutput{}, nil
+}
+
+// ListQueuesWithContext the one queue's url (if its name has the prefix)
+func (m *Memory) ListQueuesWithContext(_ aws.Context, inp *sqs.ListQueuesInput, _ ...request.Option) (*sqs.ListQueuesOutput, error) {
+	res := &sqs.ListQueuesOutput{}
+
+	if strings.HasPrefix(memoryName, aws.StringValue(inp.QueueNamePrefix)) {
+		res.QueueUrls = []*string{aws.String(memoryURL)}
+	}
+
+	return res, nil
+}
+
+// ListQueuesPagesWithContext same as ListQueuesWithContext but as a single page
+func (m *Memory) ListQueuesPagesWithContext(ctx aws.Context, inp *sqs.ListQueuesInput, fn func(*sqs.ListQueuesOutput, bool) bool, opts ...request.Option) error {
+	res, err := m.ListQueuesWithContext(ctx, inp, opts...)
+
+	if err != nil {
+		return err
+	}
+
+	fn(res, true)
+
+	return nil
+}
+
+// SetQueueAttributesWithContext add (or overwrite) queue attributes (stored as is, they don't change how the queue behaves)
+func (m *Memory) SetQueueAttributesWithContext(_ aws.Context, inp *sqs.SetQueueAttributesInput, _ ...request.Option) (*sqs.SetQueueAttributesOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for k, v := range inp.Attributes {
+		m.attrs[k] = aws.StringValue(v)
+	}
+
+	return &sqs.SetQueueAttributesOutput{}, nil
+}
+
+// TagQueueWithContext add (or overwrite) tags on the queue
+func (m *Memory) TagQueueWithContext(_ aws.Context, inp *sqs.TagQueueInput, _ ...request.Option) (*sqs.TagQueueOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for k, v := range inp.Tags {
+		m.tags[k] = aws.StringValue(v)
+	}
+
+	return &sqs.TagQueueOutput{}, nil
+}
+
+// UntagQueueWithContext remove tags from the queue
+func (m *Memory) UntagQueueWithContext(_ aws.Context, inp *sqs.UntagQueueInput, _ ...request.Option) (*sqs.UntagQueueOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, k := range inp.TagKeys {
+		delete(m.tags, aws.StringValue(k))
+	}
+
+	return &sqs.UntagQueueOutput{}, nil
+}
+
+// ListQueueTagsWithContext the queue's tags
+func (m *Memory) ListQueueTagsWithContext(_ aws.Context, _ *sqs.ListQueueTagsInput, _ ...request.Option) (*sqs.ListQueueTagsOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return &sqs.ListQueueTagsOutput{
+		Tags: aws.StringMap(m.tags),
+	}, nil
+}
+
+// GetQueueAttributesWithContext the message counts, arn, and any set attributes of the queue
+func (m *Memory) GetQueueAttributesWithContext(_ aws.Context, _ *sqs.GetQueueAttributesInput, _ ...request.Option) (*sqs.GetQueueAttributesOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.clk.Now()
+
+	// delayed messages were never received, not visible ones were
+	vis, del, inv := 0, 0, 0
+
+	for _, msg := range m.msgs {
+		switch {
+		case !msg.visible.After(now):
+			vis++
+		case msg.receives == 0:
+			del++
+		default:
+			inv++
+		}
+	}
+
+	attrs := aws.StringMap(m.attrs)
+
+	attrs[sqs.QueueAttributeNameApproximateNumberOfMessages] = aws.String(strconv.Itoa(vis))
+	attrs[sqs.QueueAttributeNameApproximateNumberOfMessagesNotVisible] = aws.String(strconv.Itoa(inv))
+	attrs[sqs.QueueAttributeNameApproximateNumberOfMessagesDelayed] = aws.String(strconv.Itoa(del))
+	attrs[sqs.QueueAttributeNameQueueArn] = aws.String("arn:aws:sqs:memory:000000000000:" + memoryName)
+
+	return &sqs.GetQueueAttributesOutput{
+		Attributes: attrs,
+	}, nil
+}
+
+// AddPermissionWithContext not supported (there are no other accounts to grant access to)
+func (m *Memory) AddPermissionWithContext(_ aws.Context, _ *sqs.AddPermissionInput, _ ...request.Option) (*sqs.AddPermissionOutput, error) {
+	return nil, memoryUnsupported("AddPermission")
+}
+
+// RemovePermissionWithContext not supported (there are no other accounts to grant access to)
+func (m *Memory) RemovePermissionWithContext(_ aws.Context, _ *sqs.RemovePermissionInput, _ ...request.Option) (*sqs.RemovePermissionOutput, error) {
+	return nil, memoryUnsupported("RemovePermission")
+}
+
+// ListDeadLetterSourceQueuesWithContext not supported (redrive policies are stored, not followed)
+func (m *Memory) ListDeadLetterSourceQueuesWithContext(_ aws.Context, _ *sqs.ListDeadLetterSourceQueuesInput, _ ...request.Option) (*sqs.ListDeadLetterSourceQueuesOutput, error) {
+	return nil, memoryUnsupported("ListDeadLetterSourceQueues")
+}
+
+// ListDeadLetterSourceQueuesPagesWithContext not supported (redrive policies are stored, not followed)
+func (m *Memory) ListDeadLetterSourceQueuesPagesWithContext(_ aws.Context, _ *sqs.ListDeadLetterSourceQueuesInput, _ func(*sqs.ListDeadLetterSourceQueuesOutput, bool) bool, _ ...request.Option) error {
+	return memoryUnsupported("ListDeadLetterSourceQueues")
+}
+
+// the sdk's other forms of each call (the client only makes the WithContext ones)
+
+// AddPermission same as AddPermissionWithContext but without a context
+func (m *Memory) AddPermission(inp *sqs.AddPermissionInput) (*sqs.AddPermissionOutput, error) {
+	return m.AddPermissionWithContext(aws.BackgroundContext(), inp)
+}
+
+// AddPermissionRequest not supported (the request fails when sent)
+func (m *Memory) AddPermissionRequest(_ *sqs.AddPermissionInput) (*request.Request, *sqs.AddPermissionOutput) {
+	return memoryRequest("AddPermission"), &sqs.AddPermissionOutput{}
+}
+
+// ChangeMessageVisibility same as ChangeMessageVisibilityWithContext but without a context
+func (m *Memory) ChangeMessageVisibility(inp *sqs.ChangeMessageVisibilityInput) (*sqs.ChangeMessageVisibilityOutput, error) {
+	return m.ChangeMessageVisibilityWithContext(aws.BackgroundContext(), inp)
+}
+
+// ChangeMessageVisibilityRequest not supported (the request fails when sent)
+func (m *Memory) ChangeMessageVisibilityRequest(_ *sqs.ChangeMessageVisibilityInput) (*request.Request, *sqs.ChangeMessageVisibilityOutput) {
+	return memoryRequest("ChangeMessageVisibility"), &sqs.ChangeMessageVisibilityOutput{}
+}
+
+// ChangeMessageVisibilityBatch same as ChangeMessageVisibilityBatchWithContext but without a context
+func (m *Memory) ChangeMessageVisibilityBatch(inp *sqs.ChangeMessageVisibilityBatchInput) (*sqs.ChangeMessageVisibilityBatchOutput, error) {
+	return m.ChangeMessageVisibilityBatchWithContext(aws.BackgroundContext(), inp)
+}
+
+// ChangeMessageVisibilityBatchRequest not supported (the request fails when sent)
+func (m *Memory) ChangeMessageVisibilityBatchRequest(_ *sqs.ChangeMessageVisibilityBatchInput) (*request.Request, *sqs.ChangeMessageVisibilityBatchOutput) {
+	return memoryRequest("ChangeMessageVisibilityBatch"), &sqs.ChangeMessageVisibilityBatchOutput{}
+}
+
+// CreateQueue same as CreateQueueWithContext but without a context
+func (m *Memory) CreateQueue(inp *sqs.CreateQueueInput) (*sqs.CreateQueueOutput, error) {
+	return m.CreateQueueWithContext(aws.BackgroundContext(), inp)
+}
+
+// CreateQueueRequest not supported (the request fails when sent)
+func (m *Memory) CreateQueueRequest(_ *sqs.CreateQueueInput) (*request.Request, *sqs.CreateQueueOutput) {
+	return memoryRequest("CreateQueue"), &sqs.CreateQueueOutput{}
+}
+
+// DeleteMessage same as DeleteMessageWithContext but without a context
+func (m *Memory) DeleteMessage(inp *sqs.DeleteMessageInput) (*sqs.DeleteMessageOutput, error) {
+	return m.DeleteMessageWithContext(aws.BackgroundContext(), inp)
+}
+
+// DeleteMessageRequest not supported (the request fails when sent)
+func (m *Memory) DeleteMessageRequest(_ *sqs.DeleteMessageInput) (*request.Request, *sqs.DeleteMessageOutput) {
+	return memoryRequest("DeleteMessage"), &sqs.DeleteMessageOutput{}
+}
+
+// DeleteMessageBatch same as DeleteMessageBatchWithContext but without a context
+func (m *Memory) DeleteMessageBatch(inp *sqs.DeleteMessageBatchInput) (*sqs.DeleteMessageBatchOutput, error) {
+	return m.DeleteMessageBatchWithContext(aws.BackgroundContext(), inp)
+}
+
+// DeleteMessageBatchRequest not supported (the request fails when sent)
+func (m *Memory) DeleteMessageBatchRequest(_ *sqs.DeleteMessageBatchInput) (*request.Request, *sqs.DeleteMessageBatchOutput) {
+	return memoryRequest("DeleteMessageBatch"), &sqs.DeleteMessageBatchOutput{}
+}
+
+// DeleteQueue same as DeleteQueueWithContext but without a context
+func (m *Memory) DeleteQueue(inp *sqs.DeleteQueueInput) (*sqs.DeleteQueueOutput, error) {
+	return m.DeleteQueueWithContext(aws.BackgroundContext(), inp)
+}
+
+// DeleteQueueRequest not supported (the request fails when sent)
+func (m *Memory) DeleteQueueRequest(_ *sqs.DeleteQueueInput) (*request.Request, *sqs.DeleteQueueOutput) {
+	return memoryRequest("DeleteQueue"), &sqs.DeleteQueueOutput{}
+}
+
+// GetQueueAttributes same as GetQueueAttributesWithContext but without a context
+func (m *Memory) GetQueueAttributes(inp *sqs.GetQueueAttributesInput) (*sqs.GetQueueAttributesOutput, error) {
+	return m.GetQueueAttributesWithContext(aws.BackgroundContext(), inp)
+}
+
+// GetQueueAttributesRequest not supported (the request fails when sent)
+func (m *Memory) GetQueueAttributesRequest(_ *sqs.GetQueueAttributesInput) (*request.Request, *sqs.GetQueueAttributesOutput) {
+	return memoryRequest("GetQueueAttributes"), &sqs.GetQueueAttributesOutput{}
+}
+
+// GetQueueUrl same as GetQueueUrlWithContext but without a context
+func (m *Memory) GetQueueUrl(inp *sqs.GetQueueUrlInput) (*sqs.GetQueueUrlOutput, error) {
+	return m.GetQueueUrlWithContext(aws.BackgroundContext(), inp)
+}
+
+// GetQueueUrlRequest not supported (the request fails when sent)
+func (m *Memory) GetQueueUrlRequest(_ *sqs.GetQueueUrlInput) (*request.Request, *sqs.GetQueueUrlOutput) {
+	return memoryRequest("GetQueueUrl"), &sqs.GetQueueUrlOutput{}
+}
+
+// ListDeadLetterSourceQueues same as ListDeadLetterSourceQueuesWithContext but without a context
+func (m *Memory) ListDeadLetterSourceQueues(inp *sqs.ListDeadLetterSourceQueuesInput) (*sqs.ListDeadLetterSourceQueuesOutput, error) {
+	return m.ListDeadLetterSourceQueuesWithContext(aws.BackgroundContext(), inp)
+}
+
+// ListDeadLetterSourceQueuesRequest not supported (the request fails when sent)
+func (m *Memory) ListDeadLetterSourceQueuesRequest(_ *sqs.ListDeadLetterSourceQueuesInput) (*request.Request, *sqs.ListDeadLetterSourceQueuesOutput) {
+	return memoryRequest("ListDeadLetterSourceQueues"), &sqs.ListDeadLetterSourceQueuesOutput{}
+}
+
+// ListDeadLetterSourceQueuesPages same as ListDeadLetterSourceQueuesPagesWithContext but without a context
+func (m *Memory) ListDeadLetterSourceQueuesPages(inp *sqs.ListDeadLetterSourceQueuesInput, fn func(*sqs.ListDeadLetterSourceQueuesOutput, bool) bool) error {
+	return m.ListDeadLetterSourceQueuesPagesWithContext(aws.BackgroundContext(), inp, fn)
+}
+
+// ListQueueTags same as ListQueueTagsWithContext but without a context
+func (m *Memory) ListQueueTags(inp *sqs.ListQueueTagsInput) (*sqs.ListQueueTagsOutput, error) {
+	return m.ListQueueTagsWithContext(aws.BackgroundContext(), inp)
+}
+
+// ListQueueTagsRequest not supported (the request fails when sent)
+func (m *Memory) ListQueueTagsRequest(_ *sqs.ListQueueTagsInput) (*request.Request, *sqs.ListQueueTagsOutput) {
+	return memoryRequest("ListQueueTags"), &sqs.ListQueueTagsOutput{}
+}
+
+// ListQueues same as ListQueuesWithContext but without a context
+func (m *Memory) ListQueues(inp *sqs.ListQueuesInput) (*sqs.ListQueuesOutput, error) {
+	return m.ListQueuesWithContext(aws.BackgroundContext(), inp)
+}
+
+// ListQueuesRequest not supported (the request fails when sent)
+func (m *Memory) ListQueuesRequest(_ *sqs.ListQueuesInput) (*request.Request, *sqs.ListQueuesOutput) {
+	return memoryRequest("ListQueues"), &sqs.ListQueuesOutput{}
+}
+
+// ListQueuesPages same as ListQueuesPagesWithContext but without a context
+func (m *Memory) ListQueuesPages(inp *sqs.ListQueuesInput, fn func(*sqs.ListQueuesOutput, bool) bool) error {
+	return m.ListQueuesPagesWithContext(aws.BackgroundContext(), inp, fn)
+}
+
+// PurgeQueue same as PurgeQueueWithContext but without a context
+func (m *Memory) PurgeQueue(inp *sqs.PurgeQueueInput) (*sqs.PurgeQueueOutput, error) {
+	return m.PurgeQueueWithContext(aws.BackgroundContext(), inp)
+}
+
+// PurgeQueueRequest not supported (the request fails when sent)
+func (m *Memory) PurgeQueueRequest(_ *sqs.PurgeQueueInput) (*request.Request, *sqs.PurgeQueueOutput) {
+	return memoryRequest("PurgeQueue"), &sqs.PurgeQueueOutput{}
+}
+
+// ReceiveMessage same as ReceiveMessageWithContext but without a context
+func (m *Memory) ReceiveMessage(inp *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
+	return m.ReceiveMessageWithContext(aws.BackgroundContext(), inp)
+}
+
+// ReceiveMessageRequest not supported (the request fails when sent)
+func (m *Memory) ReceiveMessageRequest(_ *sqs.ReceiveMessageInput) (*request.Request, *sqs.ReceiveMessageOutput) {
+	return memoryRequest("ReceiveMessage"), &sqs.ReceiveMessageOutput{}
+}
+
+// RemovePermission same as RemovePermissionWithContext but without a context
+func (m *Memory) RemovePermission(inp *sqs.RemovePermissionInput) (*sqs.RemovePermissionOutput, error) {
+	return m.RemovePermissionWithContext(aws.BackgroundContext(), inp)
+}
+
+// RemovePermissionRequest not supported (the request fails when sent)
+func (m *Memory) RemovePermissionRequest(_ *sqs.RemovePermissionInput) (*request.Request, *sqs.RemovePermissionOutput) {
+	return memoryRequest("RemovePermission"), &sqs.RemovePermissionOutput{}
+}
+
+// SendMessage same as SendMessageWithContext but without a context
+func (m *Memory) SendMessage(inp *sqs.SendMessageInput) (*sqs.SendMessageOutput, error) {
+	return m.SendMessageWithContext(aws.BackgroundContext(), inp)
+}
+
+// SendMessageRequest not supported (the request fails when sent)
+func (m *Memory) SendMessageRequest(_ *sqs.SendMessageInput) (*request.Request, *sqs.SendMessageOutput) {
+	return memoryRequest("SendMessage"), &sqs.SendMessageOutput{}
+}
+
+// SendMessageBatch same as SendMessageBatchWithContext but without a context
+func (m *Memory) SendMessageBatch(inp *sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error) {
+	return m.SendMessageBatchWithContext(aws.BackgroundContext(), inp)
+}
+
+// SendMessageBatchRequest not supported (the request fails when sent)
+func (m *Memory) SendMessageBatchRequest(_ *sqs.SendMessageBatchInput) (*request.Request, *sqs.SendMessageBatchOutput) {
+	return memoryRequest("SendMessageBatch"), &sqs.SendMessageBatchOutput{}
+}
+
+// SetQueueAttributes same as SetQueueAttributesWithContext but without a context
+func (m *Memory) SetQueueAttributes(inp *sqs.SetQueueAttributesInput) (*sqs.SetQueueAttributesOutput, error) {
+	return m.SetQueueAttributesWithContext(aws.BackgroundContext(), inp)
+}
+
+// SetQueueAttributesRequest not supported (the request fails when sent)
+func (m *Memory) SetQueueAttributesRequest(_ *sqs.SetQueueAttributesInput) (*request.Request, *sqs.SetQueueAttributesOutput) {
+	return memoryRequest("SetQueueAttributes"), &sqs.SetQueueAttributesOutput{}
+}
+
+// TagQueue same as TagQueueWithContext but without a context
+func (m *Memory) TagQueue(inp *sqs.TagQueueInput) (*sqs.TagQueueOutput, error) {
+	return m.TagQueueWithContext(aws.BackgroundContext(), inp)
+}
+
+// TagQueueRequest not supported (the request fails when sent)
+func (m *Memory) TagQueueRequest(_ *sqs.TagQueueInput) (*request.Request, *sqs.TagQueueOutput) {
+	return memoryRequest("TagQueue"), &sqs.TagQueueOutput{}
+}
+
+// UntagQueue same as UntagQueueWithContext but without a context
+func (m *Memory) UntagQueue(inp *sqs.UntagQueueInput) (*sqs.UntagQueueOutput, error) {
+	return m.UntagQueueWithContext(aws.BackgroundContext(), inp)
+}
+
+// UntagQueueRequest not supported (the request fails when sent)
+func (m *Memory) UntagQueueRequest(_ *sqs.UntagQueueInput) (*request.Request, *sqs.UntagQueueOutput) {
+	return memoryRequest("UntagQueue"), &sqs.UntagQueueOutput{}
+}
+
+// add append a new message (must hold the lock)
+func (m *Memory) add(bod string, attrs map[string]*sqs.MessageAttributeValue, del int64) *memoryMessage {
+	m.seq++
+
+	now := m.clk.Now()
+
+	msg := &memoryMessage{
+		id:      fmt.Sprintf("memory-%d", m.seq),
+		body:    bod,
+		attrs:   attrs,
+		visible: now.Add(time.Duration(del) * time.Second),
+		sent:    now,
+	}
+
+	m.msgs = append(m.msgs, msg)
+
+	return msg
+}
+
+// take hide (for vis seconds) and convert up to n visible messages (1 if not given, same as aws)
+func (m *Memory) take(n int, vis int64) []*sqs.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if n <= 0 {
+		n = 1
+	}
+
+	now := m.clk.Now()
+
+	var msgs []*sqs.Message
+
+	for _, msg := range m.msgs {
+		if len(msgs) == n {
+			break
+		}
+
+		if msg.visible.After(now) {
+			continue
+		}
+
+		// a new handle each receive, so stale ones from earlier receives are rejected
+		m.seq++
+
+		msg.handle = fmt.Sprintf("%s-%d", msg.id, m.seq)
+		msg.visible = now.Add(time.Duration(vis) * time.Second)
+		msg.receives++
+
+		msgs = append(msgs, &sqs.Message{
+			MessageId:              aws.String(msg.id),
+			Body:                   aws.String(msg.body),
+			ReceiptHandle:          aws.String(msg.handle),
+			MD5OfBody:              aws.String(checksum([]byte(msg.body))),
+			MD5OfMessageAttributes: attributesMD5(msg.attrs),
+			MessageAttributes:      msg.attrs,
+			Attributes: map[string]*string{
+				sqs.MessageSystemAttributeNameApproximateReceiveCount: aws.String(strconv.Itoa(msg.receives)),
+				sqs.MessageSystemAttributeNameSentTimestamp:           aws.String(strconv.FormatInt(msg.sent.UnixNano()/int64(time.Millisecond), 10)),
+			},
+		})
+	}
+
+	return msgs
+}
+
+// remove delete the message with the receipt handle (must hold the lock)
+func (m *Memory) remove(rh string) error {
+	for i, msg := range m.msgs {
+		if rh != "" && msg.handle == rh {
+			m.msgs = append(m.msgs[:i], m.msgs[i+1:]...)
+
+			return nil
+		}
+	}
+
+	return memoryInvalidHandle(rh)
+}
+
+// show hide the message with the receipt handle for sec seconds from now (must hold the lock)
+func (m *Memory) show(rh string, sec int64) error {
+	for _, msg := range m.msgs {
+		if rh != "" && msg.handle == rh {
+			msg.visible = m.clk.Now().Add(time.Duration(sec) * time.Second)
+
+			return nil
+		}
+	}
+
+	return memoryInvalidHandle(rh)
+}
+
+// memoryInvalidHandle the aws error for an unknown (or already deleted) receipt handle
+func memoryInvalidHandle(rh string) error {
+	return awserr.New(sqs.ErrCodeReceiptHandleIsInvalid, fmt.Sprintf("receipt handle %q is invalid", rh), nil)
+}
+
+// memoryRequest a request that fails with an unsupported error when sent (there's no http request behind it)
+func memoryRequest(op string) *request.Request {
+	return &request.Request{
+		Operation: &request.Operation{Name: op},
+		Error:     memoryUnsupported(op + "Request"),
+	}
+}
+
+// memoryUnsupported the aws error for a call the in-memory queue can't stand in for
+func memoryUnsupported(op string) error {
+	return awserr.New(sqs.ErrCodeUnsupportedOperation, op+" is not supported by the in-memory queue", nil)
+}
+
+// memoryFailure convert an aws error to a failed batch entry
+func memoryFailure(id *string, err error) *sqs.BatchResultErrorEntry {
+	ent := &sqs.BatchResultErrorEntry{
+		Id:          id,
+		SenderFault: aws.Bool(true),
+		Message:     aws.String(err.Error()),
+	}
+
+	if aer, ok := err.(awserr.Error); ok {
+		ent.Code = aws.String(aer.Code())
+		ent.Message = aws.String(aer.Message())
+	}
+
+	return ent
+}
+
+// attributesMD5 the attributes md5 aws returns (nil if there are no attributes)
+func attributesMD5(attrs map[string]*sqs.MessageAttributeValue) *string {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	return aws.String(attributesChecksum(attrs))
+}
@@ -0,0 +1,376 @@
+package sqsc
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNoopProduceConsumeDelete(t *testing.T) {
+	c := NewNoop()
+
+	if _, _, err := c.Consume(); !errors.Is(err, ErrNoMessages) {
+		t.Fatalf("got %v, want %v", err, ErrNoMessages)
+	}
+
+	if _, err := c.Produce("body", 0); err != nil {
+		t.Fatalf("Produce failed: %v", err)
+	}
+
+	bod, rh, err := c.Consume()
+
+	if err != nil || bod != "body" {
+		t.Fatalf("got %q and %v, want the produced body", bod, err)
+	}
+
+	if _, err := c.Delete(rh); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	// already deleted
+	if _, err := c.Delete(rh); err == nil {
+		t.Fatal("expected deleting twice to fail")
+	}
+
+	if _, err := c.ProduceJSON(map[string]int{"n": 1}, 0); err != nil {
+		t.Fatalf("ProduceJSON failed: %v", err)
+	}
+
+	var val map[string]int
+
+	if _, err := c.ConsumeJSON(&val); err != nil || val["n"] != 1 {
+		t.Fatalf("got %+v and %v, want the produced json", val, err)
+	}
+
+	if _, err := c.ProduceBytes([]byte{0xff, 0x00}, 0); err != nil {
+		t.Fatalf("ProduceBytes failed: %v", err)
+	}
+
+	if raw, _, err := c.ConsumeBytes(); err != nil || string(raw) != "\xff\x00" {
+		t.Fatalf("got %q and %v, want the produced bytes", raw, err)
+	}
+}
+
+func TestNoopBatches(t *testing.T) {
+	c := NewNoop()
+	c.config.VerifyMD5 = true
+
+	if _, err := c.ProduceWithAttributes("first", 0, map[string]string{"key": "value"}); err != nil {
+		t.Fatalf("ProduceWithAttributes failed: %v", err)
+	}
+
+	if _, errs, err := c.ProduceBatch([]string{"second", "third"}, 0); err != nil || errs[0] != nil || errs[1] != nil {
+		t.Fatalf("ProduceBatch failed: %v %v", err, errs)
+	}
+
+	msgs, err := c.Receive(10)
+
+	if err != nil || len(msgs) != 3 {
+		t.Fatalf("got %d messages and %v, want 3", len(msgs), err)
+	}
+
+	if n, _ := c.ApproximateNumberOfMessages(); n != 0 {
+		t.Fatalf("%d messages still visible, want 0", n)
+	}
+
+	if err := c.ChangeVisibility(msgs[0].ReceiptHandle, 0); err != nil {
+		t.Fatalf("ChangeVisibility failed: %v", err)
+	}
+
+	errs, err := c.DeleteBatch([]string{msgs[1].ReceiptHandle, msgs[2].ReceiptHandle, "unknown"})
+
+	if err != nil || errs[0] != nil || errs[1] != nil || errs[2] == nil {
+		t.Fatalf("expected only the unknown handle to fail, got %v %v", errs, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// visible again
+	msg, err := c.WaitForMessage(ctx)
+
+	if err != nil || msg.Body != "first" || msg.ReceiveCount != 2 {
+		t.Fatalf("got %+v and %v, want the first message received again", msg, err)
+	}
+
+	if err := c.Ping(); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+}
+
+func TestMemoryDelayAndVisibility(t *testing.T) {
+	clk := NewFakeClock(time.Unix(0, 0))
+	mem := NewMemoryWithClock(clk)
+
+	a, _ := NewWithClient(mem, &Config{URL: memoryURL, Timeout: 1, Clock: clk})
+	b, _ := NewWithClient(mem, &Config{URL: memoryURL, Timeout: 1, Clock: clk})
+
+	if _, err := a.Produce("now", 0); err != nil {
+		t.Fatalf("Produce failed: %v", err)
+	}
+
+	if _, err := a.Produce("later", 1); err != nil {
+		t.Fatalf("Produce failed: %v", err)
+	}
+
+	first, _ := a.Receive(10)
+
+	if len(first) != 1 || first[0].Body != "now" {
+		t.Fatalf("got %+v, want only the undelayed message", first)
+	}
+
+	// hidden from other consumers
+	if msgs, _ := b.Receive(10); len(msgs) != 0 {
+		t.Fatalf("got %+v, want nothing visible", msgs)
+	}
+
+	if n, _ := a.MessagesDelayed(); n != 1 {
+		t.Fatalf("%d messages delayed, want 1", n)
+	}
+
+	if n, _ := a.MessagesNotVisible(); n != 1 {
+		t.Fatalf("%d messages not visible, want 1", n)
+	}
+
+	clk.Advance(time.Second)
+
+	// redelivered after the visibility timeout (and the delayed one is visible)
+	msgs, _ := b.Receive(10)
+
+	if len(msgs) != 2 {
+		t.Fatalf("got %+v, want both messages", msgs)
+	}
+
+	// the handle from the earlier receive is stale
+	if _, err := a.Delete(first[0].ReceiptHandle); err == nil {
+		t.Fatal("expected a stale receipt handle to fail")
+	}
+
+	if err := a.Purge(); err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+
+	if n, _ := a.MessagesNotVisible(); n != 0 {
+		t.Fatalf("%d messages left after purging, want 0", n)
+	}
+}
+
+func TestNoopEveryMethod(t *testing.T) {
+	ctx := context.Background()
+	arn := "arn:aws:sqs:memory:000000000000:" + memoryName
+	topic := "arn:aws:sns:memory:000000000000:topic"
+	attrs := map[string]string{sqs.QueueAttributeNameDelaySeconds: "0"}
+
+	produce := func(c *SQSC, bod string) string {
+		if _, err := c.Produce(bod, 0); err != nil {
+			t.Fatalf("Produce failed: %v", err)
+		}
+
+		msgs, err := c.Receive(1)
+
+		if err != nil || len(msgs) != 1 {
+			t.Fatalf("Receive failed: %v %+v", err, msgs)
+		}
+
+		return msgs[0].ReceiptHandle
+	}
+
+	// every one of them works (none of them panic on a nil sqs client)
+	for _, tc := range []struct {
+		name string
+		fn   func(c *SQSC) error
+	}{
+		{name: "Produce", fn: func(c *SQSC) error { _, err := c.Produce("body", 0); return err }},
+		{name: "Send", fn: func(c *SQSC) error { _, err := c.Send("body"); return err }},
+		{name: "ProduceDelayed", fn: func(c *SQSC) error { _, err := c.ProduceDelayed("body", time.Second); return err }},
+		{name: "ProduceAt", fn: func(c *SQSC) error { _, err := c.ProduceAt("body", time.Now()); return err }},
+		{name: "ProduceWithAttributes", fn: func(c *SQSC) error {
+			_, err := c.ProduceWithAttributes("body", 0, map[string]string{"k": "v"})
+			return err
+		}},
+		{name: "ProduceWithMessageAttributes", fn: func(c *SQSC) error { _, err := c.ProduceWithMessageAttributes("body", 0, nil); return err }},
+		{name: "ProduceFIFO", fn: func(c *SQSC) error { _, err := c.ProduceFIFO("body", "group", "dedup"); return err }},
+		{name: "ProduceFIFODetailed", fn: func(c *SQSC) error { _, err := c.ProduceFIFODetailed("body", "group", "dedup"); return err }},
+		{name: "ProduceDetailed", fn: func(c *SQSC) error { _, err := c.ProduceDetailed("body", 0); return err }},
+		{name: "ProduceRaw", fn: func(c *SQSC) error { _, err := c.ProduceRaw("body", 0); return err }},
+		{name: "ProduceIdempotent", fn: func(c *SQSC) error { _, err := c.ProduceIdempotent("key", "body", 0); return err }},
+		{name: "ProduceTraced", fn: func(c *SQSC) error { _, err := c.ProduceTraced(ctx, "body"); return err }},
+		{name: "ProduceJSON", fn: func(c *SQSC) error { _, err := c.ProduceJSON(1, 0); return err }},
+		{name: "ProduceBytes", fn: func(c *SQSC) error { _, err := c.ProduceBytes([]byte("body"), 0); return err }},
+		{name: "ProduceBatch", fn: func(c *SQSC) error { _, _, err := c.ProduceBatch([]string{"body"}, 0); return err }},
+		{name: "ProduceBatchDetailed", fn: func(c *SQSC) error { _, err := c.ProduceBatchDetailed([]string{"body"}, 0); return err }},
+		{name: "ProduceBatchEntries", fn: func(c *SQSC) error { _, err := c.ProduceBatchEntries([]ProduceEntry{{Body: "body"}}); return err }},
+		{name: "Consume", fn: func(c *SQSC) error { _, _, err := c.Consume(); return err }},
+		{name: "ConsumeMessage", fn: func(c *SQSC) error { _, err := c.ConsumeMessage(); return err }},
+		{name: "ConsumeJSON", fn: func(c *SQSC) error { var v string; _, err := c.ConsumeJSON(&v); return err }},
+		{name: "ConsumeBytes", fn: func(c *SQSC) error { _, _, err := c.ConsumeBytes(); return err }},
+		{name: "Receive", fn: func(c *SQSC) error { _, err := c.Receive(1); return err }},
+		{name: "ReceiveWithAttributes", fn: func(c *SQSC) error { _, err := c.ReceiveWithAttributes(1); return err }},
+		{name: "ReceiveWithAttributeNames", fn: func(c *SQSC) error { _, err := c.ReceiveWithAttributeNames(1, nil, nil); return err }},
+		{name: "ReceiveWithOptions", fn: func(c *SQSC) error { _, err := c.ReceiveWithOptions(1, 1, 0); return err }},
+		{name: "ReceiveN", fn: func(c *SQSC) error { _, err := c.ReceiveN(2); return err }},
+		{name: "ReceiveFull", fn: func(c *SQSC) error { _, err := c.ReceiveFull(2, 1); return err }},
+		{name: "ReceiveWithStats", fn: func(c *SQSC) error { _, _, err := c.ReceiveWithStats(1); return err }},
+		{name: "Peek", fn: func(c *SQSC) error { _, err := c.Peek(1); return err }},
+		{name: "WaitForMessage", fn: func(c *SQSC) error { _, err := c.WaitForMessage(ctx); return err }},
+		{name: "Delete", fn: func(c *SQSC) error { _, err := c.Delete(produce(c, "body")); return err }},
+		{name: "DeleteMessage", fn: func(c *SQSC) error { return c.DeleteMessage(&Message{ReceiptHandle: produce(c, "body")}) }},
+		{name: "DeleteMessages", fn: func(c *SQSC) error {
+			_, err := c.DeleteMessages([]*Message{{ReceiptHandle: produce(c, "body")}})
+			return err
+		}},
+		{name: "DeleteBatch", fn: func(c *SQSC) error { _, err := c.DeleteBatch([]string{produce(c, "body")}); return err }},
+		{name: "ChangeVisibility", fn: func(c *SQSC) error { return c.ChangeVisibility(produce(c, "body"), 0) }},
+		{name: "ChangeVisibilityBatch", fn: func(c *SQSC) error { _, err := c.ChangeVisibilityBatch([]string{produce(c, "body")}, 0); return err }},
+		{name: "ProcessBatch", fn: func(c *SQSC) error {
+			_, _, err := c.ProcessBatch(1, func(msgs []Message) []error { return nil })
+			return err
+		}},
+		{name: "Process", fn: func(c *SQSC) error {
+			ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+			defer cancel()
+
+			return c.Process(ctx, func(Message) error { return nil })
+		}},
+		{name: "ProcessConcurrent", fn: func(c *SQSC) error {
+			ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+			defer cancel()
+
+			return c.ProcessConcurrent(ctx, 2, func(Message) error { return nil })
+		}},
+		{name: "Stream", fn: func(c *SQSC) error {
+			ctx, cancel := context.WithCancel(ctx)
+			msgs, errs := c.Stream(ctx)
+
+			cancel()
+
+			for range msgs {
+			}
+
+			return <-errs
+		}},
+		{name: "Purge", fn: (*SQSC).Purge},
+		{name: "Attributes", fn: func(c *SQSC) error { _, err := c.Attributes(); return err }},
+		{name: "SetAttributes", fn: func(c *SQSC) error { return c.SetAttributes(attrs) }},
+		{name: "EnableEncryption", fn: func(c *SQSC) error { return c.EnableEncryption("") }},
+		{name: "Ping", fn: (*SQSC).Ping},
+		{name: "ARN", fn: func(c *SQSC) error { _, err := c.ARN(); return err }},
+		{name: "Length", fn: func(c *SQSC) error { _, err := c.Length(); return err }},
+		{name: "ApproximateNumberOfMessages", fn: func(c *SQSC) error { _, err := c.ApproximateNumberOfMessages(); return err }},
+		{name: "MessagesDelayed", fn: func(c *SQSC) error { _, err := c.MessagesDelayed(); return err }},
+		{name: "MessagesNotVisible", fn: func(c *SQSC) error { _, err := c.MessagesNotVisible(); return err }},
+		{name: "ListQueues", fn: func(c *SQSC) error { _, err := c.ListQueues(""); return err }},
+		{name: "TagQueue", fn: func(c *SQSC) error { return c.TagQueue(map[string]string{"k": "v"}) }},
+		{name: "UntagQueue", fn: func(c *SQSC) error { return c.UntagQueue([]string{"k"}) }},
+		{name: "ListTags", fn: func(c *SQSC) error { _, err := c.ListTags(); return err }},
+		{name: "SetPolicy", fn: func(c *SQSC) error { return c.SetPolicy(Policy{}) }},
+		{name: "GetPolicy", fn: func(c *SQSC) error { _, err := c.GetPolicy(); return err }},
+		{name: "AllowSNSTopic", fn: func(c *SQSC) error { return c.AllowSNSTopic(topic) }},
+		{name: "SubscribeToSNS", fn: func(c *SQSC) error { _, err := c.SubscribeToSNS(&subscriber{}, topic, true); return err }},
+		{name: "SetRedrivePolicy", fn: func(c *SQSC) error { return c.SetRedrivePolicy(arn, 5) }},
+		{name: "GetRedrivePolicy", fn: func(c *SQSC) error { _, _, err := c.GetRedrivePolicy(); return err }},
+		{name: "Redrive", fn: func(c *SQSC) error { _, err := c.Redrive(memoryURL, 1); return err }},
+		{name: "CreateQueue", fn: func(c *SQSC) error { _, err := c.CreateQueue(attrs); return err }},
+		{name: "DeleteQueue", fn: (*SQSC).DeleteQueue},
+		{name: "Close", fn: func(c *SQSC) error { return c.Close(ctx) }},
+	} {
+		c := NewNoop()
+
+		// something to consume
+		if _, err := c.ProduceJSON("body", 0); err != nil {
+			t.Fatalf("ProduceJSON failed: %v", err)
+		}
+
+		if err := tc.fn(c); err != nil {
+			t.Fatalf("%s failed: %v", tc.name, err)
+		}
+	}
+}
+
+func TestMemoryAttributesAndTags(t *testing.T) {
+	c := NewNoop()
+
+	if err := c.SetRedrivePolicy("arn:aws:sqs:memory:000000000000:dlq", 5); err != nil {
+		t.Fatalf("SetRedrivePolicy failed: %v", err)
+	}
+
+	if arn, max, err := c.GetRedrivePolicy(); err != nil || arn != "arn:aws:sqs:memory:000000000000:dlq" || max != 5 {
+		t.Fatalf("got %q, %d, and %v, want the set redrive policy", arn, max, err)
+	}
+
+	if err := c.TagQueue(map[string]string{"a": "1", "b": "2"}); err != nil {
+		t.Fatalf("TagQueue failed: %v", err)
+	}
+
+	if err := c.UntagQueue([]string{"a"}); err != nil {
+		t.Fatalf("UntagQueue failed: %v", err)
+	}
+
+	if tags, err := c.ListTags(); err != nil || !reflect.DeepEqual(tags, map[string]string{"b": "2"}) {
+		t.Fatalf("got %v and %v, want only the b tag", tags, err)
+	}
+
+	if urls, err := c.ListQueues("sq"); err != nil || !reflect.DeepEqual(urls, []string{memoryURL}) {
+		t.Fatalf("got %v and %v, want the one queue", urls, err)
+	}
+
+	if urls, _ := c.ListQueues("other"); len(urls) != 0 {
+		t.Fatalf("got %v, want no queues", urls)
+	}
+
+	// back to a fresh queue
+	if err := c.DeleteQueue(); err != nil {
+		t.Fatalf("DeleteQueue failed: %v", err)
+	}
+
+	if tags, _ := c.ListTags(); len(tags) != 0 {
+		t.Fatalf("got %v, want no tags", tags)
+	}
+
+	if arn, _, _ := c.GetRedrivePolicy(); arn != "" {
+		t.Fatalf("got %q, want no redrive policy", arn)
+	}
+}
+
+func TestMemoryUnsupported(t *testing.T) {
+	mem := NewMemory()
+
+	// the plain forms work the same
+	if _, err := mem.SendMessage(&sqs.SendMessageInput{MessageBody: aws.String("body")}); err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+
+	if res, err := mem.ReceiveMessage(&sqs.ReceiveMessageInput{}); err != nil || len(res.Messages) != 1 {
+		t.Fatalf("got %+v and %v, want the sent message", res, err)
+	}
+
+	unsupported := func(op string, err error) {
+		if aer, ok := err.(awserr.Error); !ok || aer.Code() != sqs.ErrCodeUnsupportedOperation {
+			t.Fatalf("%s: got %v, want an unsupported operation error", op, err)
+		}
+	}
+
+	_, err := mem.AddPermission(&sqs.AddPermissionInput{})
+	unsupported("AddPermission", err)
+
+	_, err = mem.RemovePermission(&sqs.RemovePermissionInput{})
+	unsupported("RemovePermission", err)
+
+	_, err = mem.ListDeadLetterSourceQueues(&sqs.ListDeadLetterSourceQueuesInput{})
+	unsupported("ListDeadLetterSourceQueues", err)
+
+	err = mem.ListDeadLetterSourceQueuesPages(&sqs.ListDeadLetterSourceQueuesInput{}, func(*sqs.ListDeadLetterSourceQueuesOutput, bool) bool { return true })
+	unsupported("ListDeadLetterSourceQueuesPages", err)
+
+	req, _ := mem.SendMessageRequest(&sqs.SendMessageInput{MessageBody: aws.String("body")})
+	unsupported("SendMessageRequest", req.Send())
+
+	// and through the client's escape hatch
+	_, err = NewNoop().Raw().AddPermission(&sqs.AddPermissionInput{})
+	unsupported("Raw().AddPermission", err)
+}
@@ -0,0 +1,540 @@
+package sqsc
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxVisibility = 43200 //<< max visibility timeout (seconds)
+	maxWait       = 20    //<< max wait time (seconds)
+)
+
+// Message a message from the queue
+type Message struct {
+	ID              string               //<< message id
+	Body            string               //<< message body
+	ReceiptHandle   string               //<< receipt handle (use for deleting messages)
+	Attributes      map[string]string    //<< message attributes (only set by ReceiveWithAttributes, or if Config.FetchAttributes)
+	TypedAttributes map[string]Attribute //<< same as attributes but with the data types (see GetString, GetInt, GetFloat, GetBytes)
+	ReceiveCount    int                  //<< how many times the message has been received (including this time)
+	SentTimestamp   time.Time            //<< when the message was sent
+	System          map[string]string    //<< system attributes (i.e. SenderId, only set by ReceiveWithAttributes, or if Config.FetchSystemAttributes)
+	TraceHeader     string               //<< x-ray trace header (only set if produced with one, i.e. by ProduceTraced)
+	QueueURL        string               //<< the url of the queue it was received from
+	SequenceNumber  string               //<< sequence number (fifo queues only)
+	GroupID         string               //<< message group id (fifo queues only)
+	DeduplicationID string               //<< message deduplication id (fifo queues only)
+}
+
+// Receive receive up to n messages from the queue
+//
+// n - max number of messages (1-10)
+//
+// returns
+// - the messages (empty if the queue is empty, or no messages are visible)
+// - any error
+func (c *SQSC) Receive(n int64) ([]Message, error) {
+	return c.ReceiveWithContext(context.Background(), n)
+}
+
+// ReceiveWithContext same as Receive but with a context for cancellation
+func (c *SQSC) ReceiveWithContext(ctx context.Context, n int64) ([]Message, error) {
+	return c.receive(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(c.config.URL),
+		MaxNumberOfMessages: aws.Int64(n),
+		VisibilityTimeout:   aws.Int64(int64(c.config.Timeout)),
+		WaitTimeSeconds:     aws.Int64(int64(c.config.Wait)),
+	})
+}
+
+// ReceiveWithAttributes same as Receive but includes all the message and system attributes
+func (c *SQSC) ReceiveWithAttributes(n int64) ([]Message, error) {
+	return c.ReceiveWithAttributesWithContext(context.Background(), n)
+}
+
+// ReceiveWithAttributesWithContext same as ReceiveWithAttributes but with a context for cancellation
+func (c *SQSC) ReceiveWithAttributesWithContext(ctx context.Context, n int64) ([]Message, error) {
+	return c.ReceiveWithAttributeNamesWithContext(ctx, n, nil, nil)
+}
+
+// ReceiveWithAttributeNames same as ReceiveWithAttributes but only includes the given attributes
+//
+// n - max number of messages (1-10)
+// sys - the system attribute names (i.e. SenderId, all of them if none given)
+// attrs - the message attribute names (i.e. foo or foo.* for a prefix, all of them if none given)
+func (c *SQSC) ReceiveWithAttributeNames(n int64, sys []string, attrs []string) ([]Message, error) {
+	return c.ReceiveWithAttributeNamesWithContext(context.Background(), n, sys, attrs)
+}
+
+// ReceiveWithAttributeNamesWithContext same as ReceiveWithAttributeNames but with a context for cancellation
+func (c *SQSC) ReceiveWithAttributeNamesWithContext(ctx context.Context, n int64, sys []string, attrs []string) ([]Message, error) {
+	if len(sys) == 0 {
+		sys = []string{sqs.QueueAttributeNameAll}
+	}
+
+	if len(attrs) == 0 {
+		attrs = []string{sqs.QueueAttributeNameAll}
+	}
+
+	return c.receive(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:              aws.String(c.config.URL),
+		MaxNumberOfMessages:   aws.Int64(n),
+		VisibilityTimeout:     aws.Int64(int64(c.config.Timeout)),
+		WaitTimeSeconds:       aws.Int64(int64(c.config.Wait)),
+		AttributeNames:        aws.StringSlice(sys),
+		MessageAttributeNames: aws.StringSlice(attrs),
+	})
+}
+
+// ReceiveWithOptions same as Receive but overrides the configured visibility timeout and wait time for this call
+//
+// n - max number of messages (1-10)
+// vis - the visibility timeout in seconds (0-43200)
+// wait - the wait time in seconds (0-20)
+func (c *SQSC) ReceiveWithOptions(n int64, vis int, wait int) ([]Message, error) {
+	return c.ReceiveWithOptionsWithContext(context.Background(), n, vis, wait)
+}
+
+// ReceiveWithOptionsWithContext same as ReceiveWithOptions but with a context for cancellation
+func (c *SQSC) ReceiveWithOptionsWithContext(ctx context.Context, n int64, vis int, wait int) ([]Message, error) {
+	if vis < 0 || vis > maxVisibility {
+		return nil, ErrInvalidTimeout
+	}
+
+	if wait < 0 || wait > maxWait {
+		return nil, ErrInvalidWait
+	}
+
+	return c.receive(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(c.config.URL),
+		MaxNumberOfMessages: aws.Int64(n),
+		VisibilityTimeout:   aws.Int64(int64(vis)),
+		WaitTimeSeconds:     aws.Int64(int64(wait)),
+	})
+}
+
+// ReceiveN receive up to total messages (more than the 10 per receive aws allows)
+//
+// total - max number of messages
+//
+// the first poll uses the configured wait, the rest are short polls, it stops at total or once a poll comes back empty
+//
+// returns
+// - the messages (the ones received so far even if there's an error, so they can still be handled)
+// - any error
+func (c *SQSC) ReceiveN(total int64) ([]Message, error) {
+	return c.ReceiveNWithContext(context.Background(), total)
+}
+
+// ReceiveNWithContext same as ReceiveN but with a context for cancellation
+func (c *SQSC) ReceiveNWithContext(ctx context.Context, total int64) ([]Message, error) {
+	var msgs []Message
+
+	wait := c.config.Wait
+
+	for int64(len(msgs)) < total {
+		n := total - int64(len(msgs))
+
+		if n > maxBatchSize {
+			n = maxBatchSize
+		}
+
+		rcv, err := c.receive(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(c.config.URL),
+			MaxNumberOfMessages: aws.Int64(n),
+			VisibilityTimeout:   aws.Int64(int64(c.config.Timeout)),
+			WaitTimeSeconds:     aws.Int64(int64(wait)),
+		})
+
+		msgs = append(msgs, rcv...)
+
+		if err != nil {
+			return msgs, err
+		}
+
+		if len(rcv) == 0 {
+			break
+		}
+
+		// don't block once we've got something
+		wait = 0
+	}
+
+	return msgs, nil
+}
+
+// ReceiveFull same as Receive but tries to fill the batch with extra short polls (aws often returns fewer than asked for)
+//
+// n - max number of messages (1-10)
+// attempts - max extra short polls to try filling the batch with (empty polls count too)
+//
+// trades a little latency for fuller batches
+//
+// returns
+// - the messages (the ones received so far even if there's an error, so they can still be handled)
+// - any error
+func (c *SQSC) ReceiveFull(n int64, attempts int) ([]Message, error) {
+	return c.ReceiveFullWithContext(context.Background(), n, attempts)
+}
+
+// ReceiveFullWithContext same as ReceiveFull but with a context for cancellation
+func (c *SQSC) ReceiveFullWithContext(ctx context.Context, n int64, attempts int) ([]Message, error) {
+	msgs, err := c.ReceiveWithContext(ctx, n)
+
+	for i := 0; err == nil && i < attempts && int64(len(msgs)) < n; i++ {
+		var rcv []Message
+
+		rcv, err = c.receive(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(c.config.URL),
+			MaxNumberOfMessages: aws.Int64(n - int64(len(msgs))),
+			VisibilityTimeout:   aws.Int64(int64(c.config.Timeout)),
+			WaitTimeSeconds:     aws.Int64(0),
+		})
+
+		msgs = append(msgs, rcv...)
+	}
+
+	return msgs, err
+}
+
+// WaitForMessage keep polling until a message arrives or the context is done
+//
+// polls with the max wait time (20 seconds) regardless of the configured wait (shortened to fit the context,
+// operation timeout, and request timeout)
+//
+// returns
+// - the message
+// - any error (the context's error, i.e. context.DeadlineExceeded, if no message arrived in time)
+func (c *SQSC) WaitForMessage(ctx context.Context) (*Message, error) {
+	for {
+		msgs, err := c.receive(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(c.config.URL),
+			MaxNumberOfMessages: aws.Int64(1),
+			VisibilityTimeout:   aws.Int64(int64(c.config.Timeout)),
+			WaitTimeSeconds:     aws.Int64(maxWait),
+		})
+
+		// cancelled mid-poll is a timeout, not an aws error
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		if len(msgs) != 0 {
+			return &msgs[0], nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Peek receive up to n messages without hiding them from other consumers (visibility timeout of 0)
+//
+// n - max number of messages (1-10)
+//
+// note: this still counts as a receive (ReceiveCount goes up) and races with real consumers,
+// they can receive (and delete) the same messages at the same time - use for debugging only
+func (c *SQSC) Peek(n int64) ([]Message, error) {
+	return c.PeekWithContext(context.Background(), n)
+}
+
+// PeekWithContext same as Peek but with a context for cancellation
+func (c *SQSC) PeekWithContext(ctx context.Context, n int64) ([]Message, error) {
+	return c.receive(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:              aws.String(c.config.URL),
+		MaxNumberOfMessages:   aws.Int64(n),
+		VisibilityTimeout:     aws.Int64(0),
+		WaitTimeSeconds:       aws.Int64(int64(c.config.Wait)),
+		AttributeNames:        []*string{aws.String(sqs.QueueAttributeNameAll)},
+		MessageAttributeNames: []*string{aws.String(sqs.QueueAttributeNameAll)},
+	})
+}
+
+// DeleteMessage delete a received message
+//
+// returns
+// - any error (sqsc.ErrMissingReceiptHandle if the message is nil or has no receipt handle)
+func (c *SQSC) DeleteMessage(m *Message) error {
+	return c.DeleteMessageWithContext(context.Background(), m)
+}
+
+// DeleteMessageWithContext same as DeleteMessage but with a context for cancellation
+func (c *SQSC) DeleteMessageWithContext(ctx context.Context, m *Message) error {
+	if m == nil || m.ReceiptHandle == "" {
+		return ErrMissingReceiptHandle
+	}
+
+	_, err := c.DeleteWithContext(ctx, m.ReceiptHandle)
+
+	return err
+}
+
+// DeleteMessages delete many received messages
+//
+// returns
+// - the per-message errors (same order as ms, nil if succeeded, sqsc.ErrMissingReceiptHandle if nil or no receipt handle)
+// - any error that failed a whole request (remaining messages are not deleted)
+func (c *SQSC) DeleteMessages(ms []*Message) ([]error, error) {
+	return c.DeleteMessagesWithContext(context.Background(), ms)
+}
+
+// DeleteMessagesWithContext same as DeleteMessages but with a context for cancellation
+func (c *SQSC) DeleteMessagesWithContext(ctx context.Context, ms []*Message) ([]error, error) {
+	errs := make([]error, len(ms))
+
+	// only send the ones we can delete, remembering where they came from
+	rhs := make([]string, 0, len(ms))
+	idx := make([]int, 0, len(ms))
+
+	for i, m := range ms {
+		if m == nil || m.ReceiptHandle == "" {
+			errs[i] = ErrMissingReceiptHandle
+			continue
+		}
+
+		rhs = append(rhs, m.ReceiptHandle)
+		idx = append(idx, i)
+	}
+
+	res, err := c.DeleteBatchWithContext(ctx, rhs)
+
+	for j, e := range res {
+		errs[idx[j]] = e
+	}
+
+	return errs, err
+}
+
+// receive receive messages and convert them
+func (c *SQSC) receive(ctx context.Context, inp *sqs.ReceiveMessageInput) ([]Message, error) {
+	// fail fast rather than let aws reject it
+	if n := inp.MaxNumberOfMessages; n != nil && (*n < 1 || *n > maxBatchSize) {
+		return nil, ErrInvalidBatchSize
+	}
+
+	// need these attributes to spot encoded and offloaded bodies
+	names := []*string{aws.String(encodingAttribute)}
+
+	if c.offloading() {
+		names = append(names, aws.String(s3SizeAttribute))
+	}
+
+	// need these for the receive count, sent timestamp, and trace header
+	sys := []*string{
+		aws.String(sqs.MessageSystemAttributeNameApproximateReceiveCount),
+		aws.String(sqs.MessageSystemAttributeNameSentTimestamp),
+	}
+
+	// emulators reject attribute names they don't know (it's still included if asked for)
+	if !c.config.Compat {
+		sys = append(sys, aws.String(sqs.MessageSystemAttributeNameAwstraceHeader))
+	}
+
+	// for debugging ordering (standard queues don't have them)
+	if c.fifo() {
+		sys = append(sys,
+			aws.String(sqs.MessageSystemAttributeNameSequenceNumber),
+			aws.String(sqs.MessageSystemAttributeNameMessageGroupId),
+			aws.String(sqs.MessageSystemAttributeNameMessageDeduplicationId),
+		)
+	}
+
+	// only hand back what was asked for (everything by default if configured)
+	want := *inp
+
+	if len(want.AttributeNames) == 0 && c.config.FetchSystemAttributes {
+		want.AttributeNames = aws.StringSlice([]string{sqs.QueueAttributeNameAll})
+	}
+
+	if len(want.MessageAttributeNames) == 0 && c.config.FetchAttributes {
+		want.MessageAttributeNames = aws.StringSlice([]string{sqs.QueueAttributeNameAll})
+	}
+
+	cpy := *inp
+	cpy.MessageAttributeNames = append(names, want.MessageAttributeNames...)
+	cpy.AttributeNames = append(sys, want.AttributeNames...)
+
+	var res *sqs.ReceiveMessageOutput
+
+	err := c.call(ctx, "Receive", func(ctx context.Context) (err error) {
+		// after the operation timeout is applied so it's included
+		cpy.WaitTimeSeconds = c.deadlineWait(ctx, inp.WaitTimeSeconds)
+
+		res, err = c.sqs.ReceiveMessageWithContext(ctx, &cpy)
+
+		return err
+	})
+
+	if err != nil || res == nil {
+		return nil, err
+	}
+
+	msgs := make([]Message, 0, len(res.Messages))
+
+	// bad messages are skipped (they'll be redelivered after the visibility timeout)
+	var bad []MessageError
+
+	for i, msg := range res.Messages {
+		if err := c.prepare(ctx, msg); err != nil {
+			c.logf("sqsc: Receive skipped message %s: %v", aws.StringValue(msg.MessageId), err)
+
+			bad = append(bad, MessageError{
+				Index: i,
+				ID:    aws.StringValue(msg.MessageId),
+				Err:   err,
+			})
+
+			continue
+		}
+
+		m := message(msg, want.AttributeNames, want.MessageAttributeNames)
+		m.QueueURL = c.config.URL
+
+		msgs = append(msgs, m)
+	}
+
+	c.logf("sqsc: Receive got %d messages", len(msgs))
+
+	if len(bad) != 0 {
+		return msgs, &PartialError{
+			Received: len(res.Messages),
+			Failed:   bad,
+		}
+	}
+
+	return msgs, nil
+}
+
+// deadlineWait shorten the wait time so a long poll doesn't outlast the context's deadline or the request timeout (clamped to 0-20 seconds)
+func (c *SQSC) deadlineWait(ctx context.Context, sec *int64) *int64 {
+	if sec == nil {
+		return sec
+	}
+
+	max := int64(maxWait)
+
+	if dl, ok := ctx.Deadline(); ok {
+		if rem := int64(time.Until(dl) / time.Second); rem < max {
+			max = rem
+		}
+	}
+
+	// strictly shorter, the response has to make it back before the http client gives up
+	if c.config.RequestTimeout > 0 {
+		if rem := int64((c.config.RequestTimeout - 1) / time.Second); rem < max {
+			max = rem
+		}
+	}
+
+	if max < 0 {
+		max = 0
+	}
+
+	if *sec <= max {
+		return sec
+	}
+
+	return aws.Int64(max)
+}
+
+// prepare check and restore a received message
+func (c *SQSC) prepare(ctx context.Context, msg *sqs.Message) error {
+	if msg == nil || msg.Body == nil || msg.ReceiptHandle == nil {
+		return ErrMalformedMessage
+	}
+
+	// catch corruption in transit
+	if err := c.verify(aws.StringValue(msg.MessageId), aws.StringValue(msg.Body), msg.MessageAttributes, msg.MD5OfBody, msg.MD5OfMessageAttributes); err != nil {
+		return err
+	}
+
+	// fetch offloaded bodies from s3
+	if err := c.onload(ctx, msg); err != nil {
+		return err
+	}
+
+	// decompress encoded bodies
+	return decode(msg)
+}
+
+// message convert an sdk message keeping only the wanted attributes
+func message(msg *sqs.Message, sys []*string, attrs []*string) Message {
+	m := Message{
+		ID:            aws.StringValue(msg.MessageId),
+		Body:          aws.StringValue(msg.Body),
+		ReceiptHandle: aws.StringValue(msg.ReceiptHandle),
+	}
+
+	// system attributes are best effort
+	if cnt, err := strconv.Atoi(aws.StringValue(msg.Attributes[sqs.MessageSystemAttributeNameApproximateReceiveCount])); err == nil {
+		m.ReceiveCount = cnt
+	}
+
+	if ms, err := strconv.ParseInt(aws.StringValue(msg.Attributes[sqs.MessageSystemAttributeNameSentTimestamp]), 10, 64); err == nil {
+		m.SentTimestamp = time.Unix(0, ms*int64(time.Millisecond))
+	}
+
+	m.TraceHeader = aws.StringValue(msg.Attributes[sqs.MessageSystemAttributeNameAwstraceHeader])
+	m.SequenceNumber = aws.StringValue(msg.Attributes[sqs.MessageSystemAttributeNameSequenceNumber])
+	m.GroupID = aws.StringValue(msg.Attributes[sqs.MessageSystemAttributeNameMessageGroupId])
+	m.DeduplicationID = aws.StringValue(msg.Attributes[sqs.MessageSystemAttributeNameMessageDeduplicationId])
+
+	for k, v := range msg.Attributes {
+		if !wanted(sys, k) {
+			continue
+		}
+
+		if m.System == nil {
+			m.System = make(map[string]string, len(msg.Attributes))
+		}
+
+		m.System[k] = aws.StringValue(v)
+	}
+
+	for k, v := range msg.MessageAttributes {
+		if !wanted(attrs, k) {
+			continue
+		}
+
+		if m.Attributes == nil {
+			m.Attributes = make(map[string]string, len(msg.MessageAttributes))
+			m.TypedAttributes = make(map[string]Attribute, len(msg.MessageAttributes))
+		}
+
+		m.TypedAttributes[k] = Attribute{
+			DataType: aws.StringValue(v.DataType),
+			Value:    aws.StringValue(v.StringValue),
+			Binary:   v.BinaryValue,
+		}
+
+		// binary values are kept as raw bytes
+		if v.StringValue != nil {
+			m.Attributes[k] = *v.StringValue
+		} else {
+			m.Attributes[k] = string(v.BinaryValue)
+		}
+	}
+
+	return m
+}
+
+// wanted was the attribute asked for? (by name, All, or a foo.* prefix)
+func wanted(names []*string, name string) bool {
+	for _, n := range aws.StringValueSlice(names) {
+		if n == sqs.QueueAttributeNameAll || n == name {
+			return true
+		}
+
+		if strings.HasSuffix(n, ".*") && strings.HasPrefix(name, strings.TrimSuffix(n, "*")) {
+			return true
+		}
+	}
+
+	return false
+}
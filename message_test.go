@@ -0,0 +1,155 @@
+package sqsc
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingQueue a memory queue that records the receive inputs
+type recordingQueue struct {
+	*Memory
+	mu       sync.Mutex
+	receives []*sqs.ReceiveMessageInput
+}
+
+func (q *recordingQueue) ReceiveMessageWithContext(ctx aws.Context, inp *sqs.ReceiveMessageInput, opts ...request.Option) (*sqs.ReceiveMessageOutput, error) {
+	q.mu.Lock()
+	cpy := *inp
+	q.receives = append(q.receives, &cpy)
+	q.mu.Unlock()
+
+	return q.Memory.ReceiveMessageWithContext(ctx, inp, opts...)
+}
+
+func TestDeadlineWait(t *testing.T) {
+	for _, tc := range []struct {
+		req  time.Duration
+		ctx  time.Duration
+		want int64
+	}{
+		{want: 20},
+		{req: 2 * time.Second, want: 1},
+		{req: 2500 * time.Millisecond, want: 2},
+		{ctx: 3500 * time.Millisecond, want: 3},
+		{req: 10 * time.Second, ctx: 5500 * time.Millisecond, want: 5},
+	} {
+		c := &SQSC{config: Config{RequestTimeout: tc.req}}
+		ctx := context.Background()
+
+		if tc.ctx > 0 {
+			var cancel context.CancelFunc
+
+			ctx, cancel = context.WithTimeout(ctx, tc.ctx)
+			defer cancel()
+		}
+
+		if got := aws.Int64Value(c.deadlineWait(ctx, aws.Int64(maxWait))); got != tc.want {
+			t.Fatalf("request timeout %s and context timeout %s: got wait %d, want %d", tc.req, tc.ctx, got, tc.want)
+		}
+	}
+}
+
+func TestWaitForMessageFitsOperationTimeout(t *testing.T) {
+	q := &recordingQueue{Memory: NewMemory()}
+
+	c, _ := NewWithClient(q, &Config{
+		URL:              memoryURL,
+		Timeout:          memoryVisibility,
+		Wait:             1,
+		OperationTimeout: 1500 * time.Millisecond,
+	})
+
+	// after the first poll would have timed out
+	go func() {
+		time.Sleep(1700 * time.Millisecond)
+
+		if _, err := c.Produce("body", 0); err != nil {
+			t.Errorf("Produce failed: %v", err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	msg, err := c.WaitForMessage(ctx)
+
+	if err != nil {
+		t.Fatalf("WaitForMessage failed: %v", err)
+	}
+
+	if msg.Body != "body" {
+		t.Fatalf("got body %q, want %q", msg.Body, "body")
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, inp := range q.receives {
+		if wait := aws.Int64Value(inp.WaitTimeSeconds); wait > 1 {
+			t.Fatalf("polled for %d seconds, longer than the operation timeout", wait)
+		}
+	}
+}
+
+func TestWaitIsAlwaysSent(t *testing.T) {
+	for _, wait := range []int{-1, maxWait + 1} {
+		if _, err := NewWithClient(NewMemory(), &Config{URL: memoryURL, Wait: wait}); err != ErrInvalidWait {
+			t.Fatalf("wait %d: got %v, want %v", wait, err, ErrInvalidWait)
+		}
+	}
+
+	for _, wait := range []int{0, 1} {
+		q := &recordingQueue{Memory: NewMemory()}
+		c, _ := NewWithClient(q, &Config{URL: memoryURL, Wait: wait})
+
+		if _, err := c.Receive(1); err != nil {
+			t.Fatalf("Receive failed: %v", err)
+		}
+
+		// 0 is an explicit short poll, never left for the queue's default
+		if got := q.receives[0].WaitTimeSeconds; got == nil || *got != int64(wait) {
+			t.Fatalf("wait %d: sent %v", wait, got)
+		}
+	}
+}
+
+func TestFetchAttributes(t *testing.T) {
+	for _, tc := range []struct {
+		attrs bool
+		sys   bool
+	}{
+		{},
+		{attrs: true},
+		{sys: true},
+	} {
+		c, _ := NewWithClient(NewMemory(), &Config{
+			URL:                   memoryURL,
+			Timeout:               memoryVisibility,
+			FetchAttributes:       tc.attrs,
+			FetchSystemAttributes: tc.sys,
+		})
+
+		if _, err := c.ProduceWithAttributes("body", 0, map[string]string{"key": "value"}); err != nil {
+			t.Fatalf("ProduceWithAttributes failed: %v", err)
+		}
+
+		msgs, err := c.Receive(1)
+
+		if err != nil || len(msgs) != 1 {
+			t.Fatalf("Receive failed: %v %+v", err, msgs)
+		}
+
+		if got := msgs[0].Attributes["key"] == "value"; got != tc.attrs {
+			t.Fatalf("%+v: got attributes %+v", tc, msgs[0].Attributes)
+		}
+
+		if got := msgs[0].System != nil; got != tc.sys {
+			t.Fatalf("%+v: got system attributes %+v", tc, msgs[0].System)
+		}
+	}
+}
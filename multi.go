@@ -0,0 +1,82 @@
+package sqsc
+
+import (
+	"context"
+	"sync"
+)
+
+// MultiConsumer receives from many queues, taking turns so a busy queue doesn't starve the others
+type MultiConsumer struct {
+	mu   sync.Mutex
+	clis []*SQSC
+	next int //<< the queue to try first on the next receive
+}
+
+// NewMultiConsumer creates a new multi consumer over the given clients
+func NewMultiConsumer(clis ...*SQSC) *MultiConsumer {
+	return &MultiConsumer{
+		clis: clis,
+	}
+}
+
+// Receive receive up to n messages from the next queue (round-robin, skipping empty queues)
+//
+// n - max number of messages (1-10)
+//
+// returns
+// - the messages (Message.QueueURL is the queue each came from, empty if every queue is empty)
+// - any error (from the queue that failed, the next receive moves on to the next queue)
+//
+// note: each queue is polled with its own client's wait, so use short waits to not hold up the other queues
+func (m *MultiConsumer) Receive(n int64) ([]Message, error) {
+	return m.ReceiveWithContext(context.Background(), n)
+}
+
+// ReceiveWithContext same as Receive but with a context for cancellation
+func (m *MultiConsumer) ReceiveWithContext(ctx context.Context, n int64) ([]Message, error) {
+	for range m.clis {
+		cli := m.turn()
+
+		msgs, err := cli.ReceiveWithContext(ctx, n)
+
+		if len(msgs) != 0 || err != nil {
+			return msgs, err
+		}
+	}
+
+	return nil, nil
+}
+
+// Delete delete a received message from the queue it came from
+//
+// returns
+// - any error (sqsc.ErrUnknownQueue if it didn't come from one of the queues)
+func (m *MultiConsumer) Delete(msg *Message) error {
+	return m.DeleteWithContext(context.Background(), msg)
+}
+
+// DeleteWithContext same as Delete but with a context for cancellation
+func (m *MultiConsumer) DeleteWithContext(ctx context.Context, msg *Message) error {
+	if msg == nil || msg.ReceiptHandle == "" {
+		return ErrMissingReceiptHandle
+	}
+
+	for _, cli := range m.clis {
+		if cli.URL() == msg.QueueURL {
+			return cli.DeleteMessageWithContext(ctx, msg)
+		}
+	}
+
+	return ErrUnknownQueue
+}
+
+// turn the client whose turn it is (and move on to the next one)
+func (m *MultiConsumer) turn() *SQSC {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cli := m.clis[m.next]
+	m.next = (m.next + 1) % len(m.clis)
+
+	return cli
+}
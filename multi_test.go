@@ -0,0 +1,52 @@
+package sqsc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMultiConsumerRoundRobin(t *testing.T) {
+	a, _ := NewWithClient(NewMemory(), &Config{URL: "a", Timeout: 30})
+	b, _ := NewWithClient(NewMemory(), &Config{URL: "b", Timeout: 30})
+
+	for i := 0; i < 3; i++ {
+		if _, err := a.Produce("a", 0); err != nil {
+			t.Fatalf("Produce failed: %v", err)
+		}
+	}
+
+	if _, err := b.Produce("b", 0); err != nil {
+		t.Fatalf("Produce failed: %v", err)
+	}
+
+	mul := NewMultiConsumer(a, b)
+
+	var got []string
+
+	for i := 0; i < 5; i++ {
+		msgs, err := mul.Receive(1)
+
+		if err != nil {
+			t.Fatalf("Receive failed: %v", err)
+		}
+
+		for i := range msgs {
+			got = append(got, msgs[i].QueueURL)
+
+			if err := mul.Delete(&msgs[i]); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+		}
+	}
+
+	// takes turns, then keeps going with the one that has messages
+	if want := []string{"a", "b", "a", "a"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("received from %v, want %v", got, want)
+	}
+
+	for _, c := range []*SQSC{a, b} {
+		if n, _ := c.MessagesNotVisible(); n != 0 {
+			t.Fatalf("%d messages left on %s, want 0", n, c.URL())
+		}
+	}
+}
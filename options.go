@@ -0,0 +1,164 @@
+package sqsc
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// Option sets a config for NewWithOptions
+type Option func(*Config) error
+
+// NewWithOptions same as New but built from options instead of a config struct
+//
+// returns
+// - the client
+// - any error (same as New, or wraps sqsc.ErrConflictingOptions if two options can't be used together)
+func NewWithOptions(opts ...Option) (*SQSC, error) {
+	var cfg Config
+
+	for _, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	return New(&cfg)
+}
+
+// WithRegion the aws region (required)
+func WithRegion(reg string) Option {
+	return func(c *Config) error {
+		c.Region = reg
+
+		return nil
+	}
+}
+
+// WithQueueName the queue name to look up the url for (can't be used with WithQueueURL)
+func WithQueueName(name string) Option {
+	return func(c *Config) error {
+		if c.URL != "" {
+			return fmt.Errorf("%w: queue name and queue url", ErrConflictingOptions)
+		}
+
+		c.Queue = name
+
+		return nil
+	}
+}
+
+// WithQueueURL the queue url (can't be used with WithQueueName)
+func WithQueueURL(url string) Option {
+	return func(c *Config) error {
+		if c.Queue != "" {
+			return fmt.Errorf("%w: queue url and queue name", ErrConflictingOptions)
+		}
+
+		c.URL = url
+
+		return nil
+	}
+}
+
+// WithStaticCredentials the aws key and secret (can't be used with WithCredentials)
+func WithStaticCredentials(key string, secret string) Option {
+	return func(c *Config) error {
+		if key == "" || secret == "" {
+			return ErrMissingCredentials
+		}
+
+		if c.Credentials != nil {
+			return fmt.Errorf("%w: static credentials and a credentials provider", ErrConflictingOptions)
+		}
+
+		c.Key = key
+		c.Secret = secret
+
+		return nil
+	}
+}
+
+// WithCredentials the aws credentials provider (can't be used with WithStaticCredentials)
+func WithCredentials(prv credentials.Provider) Option {
+	return func(c *Config) error {
+		if c.Key != "" {
+			return fmt.Errorf("%w: credentials provider and static credentials", ErrConflictingOptions)
+		}
+
+		c.Credentials = prv
+
+		return nil
+	}
+}
+
+// WithRole the iam role to assume (via sts) using the other credentials
+func WithRole(arn string) Option {
+	return func(c *Config) error {
+		c.RoleARN = arn
+
+		return nil
+	}
+}
+
+// WithEndpoint the aws endpoint (i.e. for localstack)
+func WithEndpoint(url string) Option {
+	return func(c *Config) error {
+		c.Endpoint = url
+
+		return nil
+	}
+}
+
+// WithVisibilityTimeout how long received messages stay hidden (seconds)
+func WithVisibilityTimeout(sec int) Option {
+	return func(c *Config) error {
+		c.Timeout = sec
+
+		return nil
+	}
+}
+
+// WithWait the long poll wait time (seconds, 0-20)
+func WithWait(sec int) Option {
+	return func(c *Config) error {
+		c.Wait = sec
+
+		return nil
+	}
+}
+
+// WithRetries the max retries
+func WithRetries(n int) Option {
+	return func(c *Config) error {
+		c.Retries = n
+
+		return nil
+	}
+}
+
+// WithLogger log each operation
+func WithLogger(lgr Logger) Option {
+	return func(c *Config) error {
+		c.Logger = lgr
+
+		return nil
+	}
+}
+
+// WithMetrics record latencies and errors of each operation
+func WithMetrics(mtr Metrics) Option {
+	return func(c *Config) error {
+		c.Metrics = mtr
+
+		return nil
+	}
+}
+
+// WithConfig change any other config (i.e. ones without an option)
+func WithConfig(fn func(*Config)) Option {
+	return func(c *Config) error {
+		fn(c)
+
+		return nil
+	}
+}
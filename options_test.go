@@ -0,0 +1,36 @@
+package sqsc
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewWithOptionsValidates(t *testing.T) {
+	if _, err := NewWithOptions(WithRegion("us-east-1"), WithQueueName("queue"), WithQueueURL("url")); !errors.Is(err, ErrConflictingOptions) {
+		t.Fatalf("got %v, want %v", err, ErrConflictingOptions)
+	}
+
+	if _, err := NewWithOptions(WithRegion("us-east-1"), WithStaticCredentials("key", "")); err != ErrMissingCredentials {
+		t.Fatalf("got %v, want %v", err, ErrMissingCredentials)
+	}
+
+	if _, err := NewWithOptions(WithQueueURL("url")); err != ErrMissingRegion {
+		t.Fatalf("got %v, want %v", err, ErrMissingRegion)
+	}
+}
+
+func TestNewWithOptions(t *testing.T) {
+	c, err := NewWithOptions(
+		WithRegion("us-east-1"),
+		WithStaticCredentials("key", "secret"),
+		WithQueueURL("https://sqs.us-east-1.amazonaws.com/000000000000/queue"),
+	)
+
+	if err != nil {
+		t.Fatalf("NewWithOptions failed: %v", err)
+	}
+
+	if got := c.QueueName(); got != "queue" {
+		t.Fatalf("got queue name %q, want %q", got, "queue")
+	}
+}
@@ -0,0 +1,159 @@
+package sqsc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+const policyVersion = "2012-10-17" //<< the iam policy language version
+
+// Policy the queue's access policy (the Policy attribute)
+type Policy struct {
+	Version    string      `json:"Version,omitempty"` //<< the policy language version (defaults to 2012-10-17)
+	ID         string      `json:"Id,omitempty"`
+	Statements []Statement `json:"Statement"`
+}
+
+// Statement a policy statement
+type Statement struct {
+	Sid          string                       `json:"Sid,omitempty"`
+	Effect       string                       `json:"Effect"`                 //<< Allow or Deny
+	Principal    Principal                    `json:"Principal,omitempty"`    //<< who (i.e. {"Service": ["sns.amazonaws.com"]}, or {"AWS": ["*"]} for anyone)
+	NotPrincipal Principal                    `json:"NotPrincipal,omitempty"` //<< everyone but who
+	Action       Values                       `json:"Action,omitempty"`       //<< what (i.e. sqs:SendMessage)
+	NotAction    Values                       `json:"NotAction,omitempty"`    //<< everything but what
+	Resource     Values                       `json:"Resource,omitempty"`     //<< the queue arn(s)
+	NotResource  Values                       `json:"NotResource,omitempty"`  //<< everything but the queue arn(s)
+	Condition    map[string]map[string]Values `json:"Condition,omitempty"`    //<< operator => key => values (i.e. ArnEquals => aws:SourceArn => topic arn)
+}
+
+// Principal the principals of a statement (type => ids)
+//
+// "*" (anyone) is read as {"AWS": ["*"]}
+type Principal map[string]Values
+
+// UnmarshalJSON read either "*" or a map
+func (p *Principal) UnmarshalJSON(raw []byte) error {
+	var str string
+
+	if err := json.Unmarshal(raw, &str); err == nil {
+		*p = Principal{"AWS": {str}}
+
+		return nil
+	}
+
+	var m map[string]Values
+
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return err
+	}
+
+	*p = m
+
+	return nil
+}
+
+// Values one or more policy values (json allows either a string or a list)
+type Values []string
+
+// UnmarshalJSON read either a string or a list
+func (v *Values) UnmarshalJSON(raw []byte) error {
+	var str string
+
+	if err := json.Unmarshal(raw, &str); err == nil {
+		*v = Values{str}
+
+		return nil
+	}
+
+	var lst []string
+
+	if err := json.Unmarshal(raw, &lst); err != nil {
+		return err
+	}
+
+	*v = lst
+
+	return nil
+}
+
+// MarshalJSON write a single value as a string, otherwise a list
+func (v Values) MarshalJSON() ([]byte, error) {
+	if len(v) == 1 {
+		return json.Marshal(v[0])
+	}
+
+	return json.Marshal([]string(v))
+}
+
+// GetPolicy get the queue's access policy
+//
+// returns
+// - the policy (no statements if there is no policy)
+// - any error
+func (c *SQSC) GetPolicy() (Policy, error) {
+	return c.GetPolicyWithContext(context.Background())
+}
+
+// GetPolicyWithContext same as GetPolicy but with a context for cancellation
+func (c *SQSC) GetPolicyWithContext(ctx context.Context) (Policy, error) {
+	attrs, err := c.AttributesWithContext(ctx, sqs.QueueAttributeNamePolicy)
+
+	if err != nil {
+		return Policy{}, err
+	}
+
+	pol := Policy{
+		Version: policyVersion,
+	}
+
+	raw := attrs[sqs.QueueAttributeNamePolicy]
+
+	if raw == "" {
+		return pol, nil
+	}
+
+	if err := json.Unmarshal([]byte(raw), &pol); err != nil {
+		return Policy{}, fmt.Errorf("failed to parse the queue policy: %w", err)
+	}
+
+	return pol, nil
+}
+
+// SetPolicy replace the queue's access policy
+//
+// returns
+// - any error (wraps sqsc.ErrInvalidPolicy if a statement has no effect or action)
+func (c *SQSC) SetPolicy(pol Policy) error {
+	return c.SetPolicyWithContext(context.Background(), pol)
+}
+
+// SetPolicyWithContext same as SetPolicy but with a context for cancellation
+func (c *SQSC) SetPolicyWithContext(ctx context.Context, pol Policy) error {
+	if pol.Version == "" {
+		pol.Version = policyVersion
+	}
+
+	// catch the obvious mistakes before aws does (with a vaguer error)
+	for i, st := range pol.Statements {
+		if st.Effect != "Allow" && st.Effect != "Deny" {
+			return fmt.Errorf("%w: statement %d effect must be Allow or Deny", ErrInvalidPolicy, i)
+		}
+
+		if len(st.Action) == 0 && len(st.NotAction) == 0 {
+			return fmt.Errorf("%w: statement %d has no action", ErrInvalidPolicy, i)
+		}
+	}
+
+	raw, err := json.Marshal(pol)
+
+	if err != nil {
+		return err
+	}
+
+	return c.SetAttributesWithContext(ctx, map[string]string{
+		sqs.QueueAttributeNamePolicy: string(raw),
+	})
+}
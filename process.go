@@ -0,0 +1,287 @@
+package sqsc
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"sync"
+	"time"
+)
+
+// Process receive messages and pass them to the handler until the context is cancelled
+//
+// hdl - the message handler (the message is deleted if it returns nil, otherwise it will be redelivered)
+//
+// returns
+// - any polling error (nil once the context is cancelled)
+//
+// note: failed deletes don't stop processing, they're logged (and counted by the metrics) and the message is redelivered
+func (c *SQSC) Process(ctx context.Context, hdl func(Message) error) error {
+	return c.ProcessConcurrent(ctx, 1, hdl)
+}
+
+// ProcessConcurrent same as Process but fans the messages out to a pool of workers
+//
+// wrk - the number of worker goroutines (at least 1)
+// hdl - the message handler (called concurrently)
+//
+// note: at most wrk + Config.Buffer + 10 messages are in flight at once, and
+// on cancel the workers finish their current messages before this returns
+// (on Close they also finish the messages already received)
+func (c *SQSC) ProcessConcurrent(ctx context.Context, wrk int, hdl func(Message) error) error {
+	if wrk < 1 {
+		wrk = 1
+	}
+
+	// closed clients don't process
+	if !c.track() {
+		return nil
+	}
+
+	defer c.active.Done()
+
+	// stop the stream when we stop
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	msgs, errs := c.Stream(ctx)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < wrk; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for msg := range msgs {
+				c.handle(msg, hdl)
+			}
+		}()
+	}
+
+	// wait for a polling error or the stream to close
+	var err error
+
+	for e := range errs {
+		// skipped messages are just redelivered, keep going with the good ones
+		var prt *PartialError
+
+		if errors.As(e, &prt) {
+			continue
+		}
+
+		err = e
+
+		break
+	}
+
+	// drain the workers
+	cancel()
+	wg.Wait()
+
+	return err
+}
+
+// ProcessResult what ProcessBatch did with a message
+//
+// the message was deleted if both errors are nil, otherwise it's left to be redelivered
+type ProcessResult struct {
+	Message
+	Err       error //<< the handler's error (nil if it succeeded, sqsc.ErrNotHandled if the handler's slice was too short)
+	DeleteErr error //<< why it wasn't deleted even though the handler succeeded (nil if deleted, or the handler failed)
+}
+
+// ProcessBatch receive one batch of messages and delete the ones the handler succeeded on
+//
+// n - max number of messages (1-10)
+// hdl - the batch handler, returns the per-message errors (same order as the messages, nil if succeeded)
+//
+// failed messages (and any the handler didn't return an error for, if its slice is short) are left to be redelivered
+//
+// returns
+// - how many messages were deleted (0 if the queue is empty)
+// - the per-message results (same order as the messages passed to the handler) - to tell handler failures from delete failures
+// - any receive error, or the error that failed the whole delete request (skipped bad messages are not an error, they're just redelivered)
+func (c *SQSC) ProcessBatch(n int64, hdl func([]Message) []error) (int, []ProcessResult, error) {
+	return c.ProcessBatchWithContext(context.Background(), n, hdl)
+}
+
+// ProcessBatchWithContext same as ProcessBatch but with a context for cancellation
+func (c *SQSC) ProcessBatchWithContext(ctx context.Context, n int64, hdl func([]Message) []error) (int, []ProcessResult, error) {
+	msgs, err := c.ReceiveWithContext(ctx, n)
+
+	// skipped messages are just redelivered, keep going with the good ones
+	var prt *PartialError
+
+	if err != nil && !errors.As(err, &prt) {
+		return 0, nil, err
+	}
+
+	if len(msgs) == 0 {
+		return 0, nil, nil
+	}
+
+	errs := hdl(msgs)
+
+	res := make([]ProcessResult, len(msgs))
+
+	// only delete the ones that definitely succeeded
+	var del []*Message
+	var idx []int
+
+	for i := range msgs {
+		res[i].Message = msgs[i]
+		res[i].Err = ErrNotHandled
+
+		if i < len(errs) {
+			res[i].Err = errs[i]
+		}
+
+		if res[i].Err == nil {
+			del = append(del, &msgs[i])
+			idx = append(idx, i)
+		}
+	}
+
+	if len(del) == 0 {
+		return 0, res, nil
+	}
+
+	// not using the context so a cancel doesn't strand handled messages
+	des, err := c.DeleteMessagesWithContext(context.Background(), del)
+
+	cnt := 0
+
+	for j, i := range idx {
+		switch {
+		case des[j] != nil:
+			res[i].DeleteErr = des[j]
+		case err != nil:
+			// the whole request failed, so it wasn't deleted either
+			res[i].DeleteErr = err
+		default:
+			cnt++
+		}
+	}
+
+	return cnt, res, err
+}
+
+// handle run the handler and delete the message if it succeeded
+//
+// failed deletes are only logged (i.e. the handler outran the visibility timeout), the message is just redelivered
+func (c *SQSC) handle(msg Message, hdl func(Message) error) {
+	// poison message, stop retrying it
+	if c.config.MaxReceives > 0 && msg.ReceiveCount > c.config.MaxReceives {
+		if err := c.deadLetter(msg); err != nil {
+			c.logf("sqsc: DeadLetter left message %s to be redelivered: %v", msg.ID, err)
+		}
+
+		return
+	}
+
+	// keep the message invisible while the handler runs
+	stop := c.heartbeat(msg.ReceiptHandle)
+	err := hdl(msg)
+	stop()
+
+	// leave it to be redelivered
+	if err != nil {
+		return
+	}
+
+	// not using the stream context so a cancel doesn't strand a handled message (call already logs and counts failures)
+	_, _ = c.DeleteWithContext(context.Background(), msg.ReceiptHandle)
+}
+
+// deadLetter send the message to the dead letter queue (if configured) and delete it
+//
+// it's sent like any other message (encoded, offloaded, throttled) with the attributes it was received with and its fifo group
+func (c *SQSC) deadLetter(msg Message) error {
+	ctx := context.Background()
+
+	if c.config.DeadLetterURL != "" {
+		inp := &sqs.SendMessageInput{
+			QueueUrl:    aws.String(c.config.DeadLetterURL),
+			MessageBody: aws.String(msg.Body),
+		}
+
+		if len(msg.TypedAttributes) != 0 {
+			inp.MessageAttributes = make(map[string]*sqs.MessageAttributeValue, len(msg.TypedAttributes))
+		}
+
+		for k, v := range msg.TypedAttributes {
+			val := &sqs.MessageAttributeValue{
+				DataType:    aws.String(v.DataType),
+				BinaryValue: v.Binary,
+			}
+
+			// binary ones only have the bytes
+			if v.Binary == nil {
+				val.StringValue = aws.String(v.Value)
+			}
+
+			inp.MessageAttributes[k] = val
+		}
+
+		// fifo messages keep their group (the message id makes sure it isn't dropped as a duplicate)
+		if msg.GroupID != "" {
+			inp.MessageGroupId = aws.String(msg.GroupID)
+			inp.MessageDeduplicationId = aws.String(msg.ID)
+		}
+
+		// leave it to be redelivered so it isn't lost
+		if _, err := c.send(ctx, inp); err != nil {
+			return err
+		}
+	}
+
+	c.logf("sqsc: DeadLetter gave up on message %s after %d receives", msg.ID, msg.ReceiveCount)
+
+	_, err := c.DeleteWithContext(ctx, msg.ReceiptHandle)
+
+	return err
+}
+
+// heartbeat periodically extend the visibility timeout of a message until stopped
+func (c *SQSC) heartbeat(rh string) func() {
+	ext := c.config.Extension
+
+	if ext == 0 {
+		ext = c.config.Timeout
+	}
+
+	// disabled (or nothing to extend it to)
+	if c.config.Heartbeat <= 0 || ext <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		every := time.Duration(c.config.Heartbeat) * time.Second
+
+		for {
+			select {
+			case <-c.config.Clock.After(every):
+				// best effort, if it fails the message is just redelivered early
+				_ = c.ChangeVisibility(rh, ext)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
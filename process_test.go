@@ -0,0 +1,282 @@
+package sqsc
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingQueue hands out a fixed number of messages and counts the deletes
+type countingQueue struct {
+	sqsiface.SQSAPI
+	mu      sync.Mutex
+	left    int
+	deletes int32
+	fail    bool //<< fail every delete
+}
+
+func (q *countingQueue) ReceiveMessageWithContext(ctx aws.Context, _ *sqs.ReceiveMessageInput, _ ...request.Option) (*sqs.ReceiveMessageOutput, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	out := &sqs.ReceiveMessageOutput{}
+
+	for i := 0; i < maxBatchSize && q.left > 0; i++ {
+		q.left--
+
+		out.Messages = append(out.Messages, &sqs.Message{
+			MessageId:     aws.String(strconv.Itoa(q.left)),
+			Body:          aws.String("body"),
+			ReceiptHandle: aws.String("handle"),
+		})
+	}
+
+	// don't spin once it's empty
+	if len(out.Messages) == 0 {
+		select {
+		case <-ctx.Done():
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	return out, nil
+}
+
+func (q *countingQueue) DeleteMessageWithContext(_ aws.Context, _ *sqs.DeleteMessageInput, _ ...request.Option) (*sqs.DeleteMessageOutput, error) {
+	atomic.AddInt32(&q.deletes, 1)
+
+	if q.fail {
+		return nil, awserr.New(sqs.ErrCodeReceiptHandleIsInvalid, "invalid", nil)
+	}
+
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func TestProcessConcurrent(t *testing.T) {
+	q := &countingQueue{left: 95}
+	c, _ := NewWithClient(q, &Config{URL: "queue"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	var handled int32
+
+	err := c.ProcessConcurrent(ctx, 4, func(Message) error {
+		// every 5th fails and isn't deleted
+		if atomic.AddInt32(&handled, 1)%5 == 0 {
+			return errors.New("failed")
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("ProcessConcurrent failed: %v", err)
+	}
+
+	if handled != 95 {
+		t.Fatalf("handled %d messages, want 95", handled)
+	}
+
+	if q.deletes != 76 {
+		t.Fatalf("deleted %d messages, want 76", q.deletes)
+	}
+}
+
+func TestProcessKeepsGoingAfterFailedDeletes(t *testing.T) {
+	q := &countingQueue{left: 20, fail: true}
+	c, _ := NewWithClient(q, &Config{URL: "queue"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	var handled int32
+
+	err := c.Process(ctx, func(Message) error {
+		atomic.AddInt32(&handled, 1)
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+
+	if handled != 20 || q.deletes != 20 {
+		t.Fatalf("handled %d and deleted %d messages, want 20 and 20", handled, q.deletes)
+	}
+}
+
+// deadLetterQueue a memory queue that captures (or fails) what's sent to the dead letter queue
+type deadLetterQueue struct {
+	*Memory
+	mu   sync.Mutex
+	sent []*sqs.SendMessageInput
+	fail bool
+}
+
+func (q *deadLetterQueue) SendMessageWithContext(ctx aws.Context, inp *sqs.SendMessageInput, opts ...request.Option) (*sqs.SendMessageOutput, error) {
+	if aws.StringValue(inp.QueueUrl) != "dlq" {
+		return q.Memory.SendMessageWithContext(ctx, inp, opts...)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.fail {
+		return nil, awserr.New(sqs.ErrCodeQueueDoesNotExist, "no dlq", nil)
+	}
+
+	q.sent = append(q.sent, inp)
+
+	return &sqs.SendMessageOutput{
+		MessageId: aws.String("dlq-id"),
+	}, nil
+}
+
+func TestProcessDeadLetter(t *testing.T) {
+	for _, fail := range []bool{false, true} {
+		q := &deadLetterQueue{Memory: NewMemory(), fail: fail}
+
+		c, _ := NewWithClient(q, &Config{
+			URL:             "queue",
+			Timeout:         30,
+			Wait:            1,
+			MaxReceives:     2,
+			DeadLetterURL:   "dlq",
+			FetchAttributes: true,
+			Compress:        true,
+		})
+
+		if _, err := c.ProduceWithAttributes("poison", 0, map[string]string{"key": "value"}); err != nil {
+			t.Fatalf("ProduceWithAttributes failed: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+
+		var handled int32
+
+		err := c.Process(ctx, func(msg Message) error {
+			atomic.AddInt32(&handled, 1)
+
+			// redeliver it right away
+			if err := c.ChangeVisibility(msg.ReceiptHandle, 0); err != nil {
+				t.Errorf("ChangeVisibility failed: %v", err)
+			}
+
+			return errors.New("failed")
+		})
+
+		cancel()
+
+		if err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+
+		if handled != 2 {
+			t.Fatalf("handled the message %d times, want 2", handled)
+		}
+
+		// still there, hidden by the receive that tried to dead letter it
+		cnt, _ := c.MessagesNotVisible()
+
+		if fail {
+			if len(q.sent) != 0 || cnt != 1 {
+				t.Fatalf("expected the message to be left for redelivery, got %d sent and %d left", len(q.sent), cnt)
+			}
+
+			continue
+		}
+
+		if len(q.sent) != 1 || cnt != 0 {
+			t.Fatalf("expected the message to be moved, got %d sent and %d left", len(q.sent), cnt)
+		}
+
+		inp := q.sent[0]
+
+		// sent like any other message
+		if aws.StringValue(inp.MessageAttributes[encodingAttribute].StringValue) != "gzip" {
+			t.Fatalf("expected a compressed body, got %+v", inp.MessageAttributes)
+		}
+
+		if aws.StringValue(inp.MessageAttributes["key"].StringValue) != "value" {
+			t.Fatalf("expected the attributes to be kept, got %+v", inp.MessageAttributes)
+		}
+	}
+}
+
+func TestProcessBatch(t *testing.T) {
+	c := NewNoop()
+
+	if _, _, err := c.ProduceBatch([]string{"a", "b", "c"}, 0); err != nil {
+		t.Fatalf("ProduceBatch failed: %v", err)
+	}
+
+	failed := errors.New("failed")
+
+	// the last one has no result
+	cnt, res, err := c.ProcessBatch(10, func(msgs []Message) []error {
+		return []error{nil, failed}
+	})
+
+	if err != nil {
+		t.Fatalf("ProcessBatch failed: %v", err)
+	}
+
+	if cnt != 1 || len(res) != 3 {
+		t.Fatalf("deleted %d with %d results, want 1 and 3", cnt, len(res))
+	}
+
+	if res[0].Err != nil || res[0].DeleteErr != nil {
+		t.Fatalf("expected the first to be deleted, got %+v", res[0])
+	}
+
+	if res[1].Err != failed || res[1].DeleteErr != nil || res[1].ReceiptHandle == "" {
+		t.Fatalf("expected the second to fail with its handle, got %+v", res[1])
+	}
+
+	if !errors.Is(res[2].Err, ErrNotHandled) {
+		t.Fatalf("got %v, want %v", res[2].Err, ErrNotHandled)
+	}
+
+	if n, _ := c.MessagesNotVisible(); n != 2 {
+		t.Fatalf("%d messages left to be redelivered, want 2", n)
+	}
+
+	// redeliver the failed one right away
+	if err := c.ChangeVisibility(res[1].ReceiptHandle, 0); err != nil {
+		t.Fatalf("ChangeVisibility failed: %v", err)
+	}
+
+	// the handle goes stale (someone else received it) before the handler finishes
+	cnt, res, err = c.ProcessBatch(10, func(msgs []Message) []error {
+		for _, msg := range msgs {
+			if err := c.ChangeVisibility(msg.ReceiptHandle, 0); err != nil {
+				t.Errorf("ChangeVisibility failed: %v", err)
+			}
+
+			if _, err := c.Receive(1); err != nil {
+				t.Errorf("Receive failed: %v", err)
+			}
+		}
+
+		return []error{nil}
+	})
+
+	if err != nil {
+		t.Fatalf("ProcessBatch failed: %v", err)
+	}
+
+	if cnt != 0 || len(res) != 1 || res[0].Err != nil || res[0].DeleteErr == nil {
+		t.Fatalf("expected a delete failure, got %d deleted and %+v", cnt, res)
+	}
+}
@@ -0,0 +1,464 @@
+package sqsc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultKMSKey  = "alias/aws/sqs"  //<< the aws managed sqs kms key
+	recreateWindow = 65 * time.Second //<< how long aws blocks recreating a deleted queue (60 seconds, plus some slack)
+	recreateDelay  = time.Second      //<< first delay between recreate attempts (doubles up to 10 seconds)
+)
+
+// CreateQueue create the queue using the configured queue name
+//
+// attrs - the queue attributes (i.e. VisibilityTimeout, FifoQueue, etc) - can be nil
+//
+// returns
+// - the queue url (also used by the client from now on)
+// - any error (matches sqsc.ErrQueueDeletedRecently if deleted in the last 60 seconds, unless Config.WaitForQueue is set)
+func (c *SQSC) CreateQueue(attrs map[string]string) (string, error) {
+	return c.CreateQueueWithContext(context.Background(), attrs)
+}
+
+// CreateQueueWithContext same as CreateQueue but with a context for cancellation
+func (c *SQSC) CreateQueueWithContext(ctx context.Context, attrs map[string]string) (string, error) {
+	inp := sqs.CreateQueueInput{
+		QueueName: aws.String(c.config.Queue),
+	}
+
+	if len(attrs) != 0 {
+		inp.Attributes = aws.StringMap(attrs)
+	}
+
+	var res *sqs.CreateQueueOutput
+
+	create := func() error {
+		return c.call(ctx, "CreateQueue", func(ctx context.Context) (err error) {
+			res, err = c.sqs.CreateQueueWithContext(ctx, &inp)
+
+			return err
+		})
+	}
+
+	err := create()
+
+	// wait out the recreate window
+	if c.config.WaitForQueue {
+		end := c.config.Clock.Now().Add(recreateWindow)
+		del := recreateDelay
+
+		for errors.Is(err, ErrQueueDeletedRecently) && c.config.Clock.Now().Before(end) {
+			select {
+			case <-c.config.Clock.After(del):
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+
+			del *= 2
+
+			if del > 10*time.Second {
+				del = 10 * time.Second
+			}
+
+			err = create()
+		}
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	// cache the url for the other operations
+	c.config.URL = aws.StringValue(res.QueueUrl)
+
+	return c.config.URL, nil
+}
+
+// DeleteQueue delete the queue
+//
+// note: deleting a queue that does not exist is not an error
+//
+// returns
+// - any error
+func (c *SQSC) DeleteQueue() error {
+	return c.DeleteQueueWithContext(context.Background())
+}
+
+// DeleteQueueWithContext same as DeleteQueue but with a context for cancellation
+func (c *SQSC) DeleteQueueWithContext(ctx context.Context) error {
+	err := c.call(ctx, "DeleteQueue", func(ctx context.Context) error {
+		_, err := c.sqs.DeleteQueueWithContext(ctx, &sqs.DeleteQueueInput{
+			QueueUrl: aws.String(c.config.URL),
+		})
+
+		return err
+	})
+
+	// already gone is good enough
+	if errors.Is(err, ErrQueueNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+// Purge delete all the messages in the queue
+//
+// note: aws only allows one purge per queue every 60 seconds
+//
+// returns
+// - any error (wraps sqsc.ErrPurgeInProgress if purged in the last 60 seconds)
+func (c *SQSC) Purge() error {
+	return c.PurgeWithContext(context.Background())
+}
+
+// PurgeWithContext same as Purge but with a context for cancellation
+func (c *SQSC) PurgeWithContext(ctx context.Context) error {
+	err := c.call(ctx, "Purge", func(ctx context.Context) error {
+		_, err := c.sqs.PurgeQueueWithContext(ctx, &sqs.PurgeQueueInput{
+			QueueUrl: aws.String(c.config.URL),
+		})
+
+		return err
+	})
+
+	return err
+}
+
+// Attributes get the queue attributes
+//
+// names - the attribute names (all attributes if none given)
+//
+// returns
+// - the attributes (name => value)
+// - any error
+func (c *SQSC) Attributes(names ...string) (map[string]string, error) {
+	return c.AttributesWithContext(context.Background(), names...)
+}
+
+// AttributesWithContext same as Attributes but with a context for cancellation
+func (c *SQSC) AttributesWithContext(ctx context.Context, names ...string) (map[string]string, error) {
+	if len(names) == 0 {
+		names = []string{sqs.QueueAttributeNameAll}
+	}
+
+	var res *sqs.GetQueueAttributesOutput
+
+	err := c.call(ctx, "Attributes", func(ctx context.Context) (err error) {
+		res, err = c.sqs.GetQueueAttributesWithContext(ctx, &sqs.GetQueueAttributesInput{
+			QueueUrl:       aws.String(c.config.URL),
+			AttributeNames: aws.StringSlice(names),
+		})
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return aws.StringValueMap(res.Attributes), nil
+}
+
+// SetAttributes set the queue attributes
+//
+// attrs - the attributes to set (name => value)
+//
+// returns
+// - any error
+func (c *SQSC) SetAttributes(attrs map[string]string) error {
+	return c.SetAttributesWithContext(context.Background(), attrs)
+}
+
+// SetAttributesWithContext same as SetAttributes but with a context for cancellation
+func (c *SQSC) SetAttributesWithContext(ctx context.Context, attrs map[string]string) error {
+	for k := range attrs {
+		if k == "" {
+			return ErrEmptyAttributeName
+		}
+	}
+
+	err := c.call(ctx, "SetAttributes", func(ctx context.Context) error {
+		_, err := c.sqs.SetQueueAttributesWithContext(ctx, &sqs.SetQueueAttributesInput{
+			QueueUrl:   aws.String(c.config.URL),
+			Attributes: aws.StringMap(attrs),
+		})
+
+		return err
+	})
+
+	return err
+}
+
+// EnableEncryption turn on server-side encryption with a kms key
+//
+// key - the kms key id, arn, or alias (blank for the aws managed key, alias/aws/sqs)
+//
+// note: to also set the data key reuse period use SetAttributes with KmsMasterKeyId and KmsDataKeyReusePeriodSeconds
+//
+// returns
+// - any error
+func (c *SQSC) EnableEncryption(key string) error {
+	return c.EnableEncryptionWithContext(context.Background(), key)
+}
+
+// EnableEncryptionWithContext same as EnableEncryption but with a context for cancellation
+func (c *SQSC) EnableEncryptionWithContext(ctx context.Context, key string) error {
+	if key == "" {
+		key = defaultKMSKey
+	}
+
+	return c.SetAttributesWithContext(ctx, map[string]string{
+		sqs.QueueAttributeNameKmsMasterKeyId: key,
+	})
+}
+
+// Ping check the queue is reachable (and readable with the configured credentials)
+//
+// returns
+// - any error (nil if healthy)
+func (c *SQSC) Ping() error {
+	return c.PingWithContext(context.Background())
+}
+
+// PingWithContext same as Ping but with a context for cancellation
+func (c *SQSC) PingWithContext(ctx context.Context) error {
+	_, err := c.AttributesWithContext(ctx, sqs.QueueAttributeNameQueueArn)
+
+	return err
+}
+
+// ARN get the queue's arn (i.e. for iam policies, sns subscriptions, or lambda event sources)
+//
+// fetched once then cached for the life of the client
+func (c *SQSC) ARN() (string, error) {
+	return c.ARNWithContext(context.Background())
+}
+
+// ARNWithContext same as ARN but with a context for cancellation
+func (c *SQSC) ARNWithContext(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	arn := c.arn
+	c.mu.Unlock()
+
+	if arn != "" {
+		return arn, nil
+	}
+
+	attrs, err := c.AttributesWithContext(ctx, sqs.QueueAttributeNameQueueArn)
+
+	if err != nil {
+		return "", err
+	}
+
+	arn = attrs[sqs.QueueAttributeNameQueueArn]
+
+	c.mu.Lock()
+	c.arn = arn
+	c.mu.Unlock()
+
+	return arn, nil
+}
+
+// Length get the approximate queue depth (same as ApproximateNumberOfMessages)
+//
+// returns
+// - the approximate number of visible messages
+// - any error (wraps sqsc.ErrMissingAttribute if aws didn't return it, or the parse error if it's not a number)
+func (c *SQSC) Length() (int, error) {
+	return c.LengthWithContext(context.Background())
+}
+
+// LengthWithContext same as Length but with a context for cancellation
+func (c *SQSC) LengthWithContext(ctx context.Context) (int, error) {
+	return c.ApproximateNumberOfMessagesWithContext(ctx)
+}
+
+// ApproximateNumberOfMessages get the approximate number of visible messages in the queue
+func (c *SQSC) ApproximateNumberOfMessages() (int, error) {
+	return c.ApproximateNumberOfMessagesWithContext(context.Background())
+}
+
+// ApproximateNumberOfMessagesWithContext same as ApproximateNumberOfMessages but with a context for cancellation
+func (c *SQSC) ApproximateNumberOfMessagesWithContext(ctx context.Context) (int, error) {
+	return c.count(ctx, sqs.QueueAttributeNameApproximateNumberOfMessages)
+}
+
+// MessagesDelayed get the approximate number of delayed messages in the queue (not yet visible)
+func (c *SQSC) MessagesDelayed() (int, error) {
+	return c.MessagesDelayedWithContext(context.Background())
+}
+
+// MessagesDelayedWithContext same as MessagesDelayed but with a context for cancellation
+func (c *SQSC) MessagesDelayedWithContext(ctx context.Context) (int, error) {
+	return c.count(ctx, sqs.QueueAttributeNameApproximateNumberOfMessagesDelayed)
+}
+
+// MessagesNotVisible get the approximate number of in flight messages in the queue (received but not deleted yet)
+func (c *SQSC) MessagesNotVisible() (int, error) {
+	return c.MessagesNotVisibleWithContext(context.Background())
+}
+
+// MessagesNotVisibleWithContext same as MessagesNotVisible but with a context for cancellation
+func (c *SQSC) MessagesNotVisibleWithContext(ctx context.Context) (int, error) {
+	return c.count(ctx, sqs.QueueAttributeNameApproximateNumberOfMessagesNotVisible)
+}
+
+// count get a numeric queue attribute
+func (c *SQSC) count(ctx context.Context, name string) (int, error) {
+	attrs, err := c.AttributesWithContext(ctx, name)
+
+	if err != nil {
+		return 0, err
+	}
+
+	val, ok := attrs[name]
+
+	if !ok {
+		return 0, fmt.Errorf("%w: queue attribute %s", ErrMissingAttribute, name)
+	}
+
+	num, err := strconv.Atoi(val)
+
+	if err != nil {
+		return 0, fmt.Errorf("queue attribute %s is not a number: %w", name, err)
+	}
+
+	return num, nil
+}
+
+// ListQueues list the queue urls in the account (and region)
+//
+// prefix - only list queues with names starting with this (all queues if blank)
+//
+// returns
+// - the queue urls (all pages)
+// - any error
+func (c *SQSC) ListQueues(prefix string) ([]string, error) {
+	return c.ListQueuesWithContext(context.Background(), prefix)
+}
+
+// ListQueuesWithContext same as ListQueues but with a context for cancellation
+func (c *SQSC) ListQueuesWithContext(ctx context.Context, prefix string) ([]string, error) {
+	inp := sqs.ListQueuesInput{
+		MaxResults: aws.Int64(1000),
+	}
+
+	if prefix != "" {
+		inp.QueueNamePrefix = aws.String(prefix)
+	}
+
+	var urls []string
+
+	err := c.call(ctx, "ListQueues", func(ctx context.Context) error {
+		// start over if retried, so pages from the failed attempt aren't repeated
+		urls = nil
+
+		return c.sqs.ListQueuesPagesWithContext(ctx, &inp, func(res *sqs.ListQueuesOutput, _ bool) bool {
+			urls = append(urls, aws.StringValueSlice(res.QueueUrls)...)
+
+			return true
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+// TagQueue add (or overwrite) tags on the queue
+//
+// tags - the tags (key => value)
+//
+// returns
+// - any error
+func (c *SQSC) TagQueue(tags map[string]string) error {
+	return c.TagQueueWithContext(context.Background(), tags)
+}
+
+// TagQueueWithContext same as TagQueue but with a context for cancellation
+func (c *SQSC) TagQueueWithContext(ctx context.Context, tags map[string]string) error {
+	for k := range tags {
+		if k == "" {
+			return ErrEmptyTagKey
+		}
+	}
+
+	err := c.call(ctx, "TagQueue", func(ctx context.Context) error {
+		_, err := c.sqs.TagQueueWithContext(ctx, &sqs.TagQueueInput{
+			QueueUrl: aws.String(c.config.URL),
+			Tags:     aws.StringMap(tags),
+		})
+
+		return err
+	})
+
+	return err
+}
+
+// UntagQueue remove tags from the queue
+//
+// keys - the tag keys to remove
+//
+// returns
+// - any error
+func (c *SQSC) UntagQueue(keys []string) error {
+	return c.UntagQueueWithContext(context.Background(), keys)
+}
+
+// UntagQueueWithContext same as UntagQueue but with a context for cancellation
+func (c *SQSC) UntagQueueWithContext(ctx context.Context, keys []string) error {
+	for _, k := range keys {
+		if k == "" {
+			return ErrEmptyTagKey
+		}
+	}
+
+	err := c.call(ctx, "UntagQueue", func(ctx context.Context) error {
+		_, err := c.sqs.UntagQueueWithContext(ctx, &sqs.UntagQueueInput{
+			QueueUrl: aws.String(c.config.URL),
+			TagKeys:  aws.StringSlice(keys),
+		})
+
+		return err
+	})
+
+	return err
+}
+
+// ListTags get the queue's tags
+//
+// returns
+// - the tags (key => value)
+// - any error
+func (c *SQSC) ListTags() (map[string]string, error) {
+	return c.ListTagsWithContext(context.Background())
+}
+
+// ListTagsWithContext same as ListTags but with a context for cancellation
+func (c *SQSC) ListTagsWithContext(ctx context.Context) (map[string]string, error) {
+	var res *sqs.ListQueueTagsOutput
+
+	err := c.call(ctx, "ListTags", func(ctx context.Context) (err error) {
+		res, err = c.sqs.ListQueueTagsWithContext(ctx, &sqs.ListQueueTagsInput{
+			QueueUrl: aws.String(c.config.URL),
+		})
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return aws.StringValueMap(res.Tags), nil
+}
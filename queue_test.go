@@ -0,0 +1,40 @@
+package sqsc
+
+import (
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"testing"
+)
+
+// bareQueue a memory queue that returns no attributes
+type bareQueue struct {
+	*Memory
+}
+
+func (bareQueue) GetQueueAttributesWithContext(_ aws.Context, _ *sqs.GetQueueAttributesInput, _ ...request.Option) (*sqs.GetQueueAttributesOutput, error) {
+	return &sqs.GetQueueAttributesOutput{}, nil
+}
+
+func TestLength(t *testing.T) {
+	c := NewNoop()
+
+	if n, err := c.Length(); n != 0 || err != nil {
+		t.Fatalf("got %d and %v, want 0", n, err)
+	}
+
+	if _, _, err := c.ProduceBatch([]string{"a", "b"}, 0); err != nil {
+		t.Fatalf("ProduceBatch failed: %v", err)
+	}
+
+	if n, err := c.Length(); n != 2 || err != nil {
+		t.Fatalf("got %d and %v, want 2", n, err)
+	}
+
+	c, _ = NewWithClient(bareQueue{NewMemory()}, &Config{URL: memoryURL})
+
+	if _, err := c.Length(); !errors.Is(err, ErrMissingAttribute) {
+		t.Fatalf("got %v, want %v", err, ErrMissingAttribute)
+	}
+}
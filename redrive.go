@@ -0,0 +1,231 @@
+package sqsc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"strconv"
+)
+
+const (
+	maxReceiveCount   = 1000 //<< the aws max for a redrive policy's max receive count
+	redriveVisibility = 30   //<< visibility timeout while redriving if none is configured (seconds)
+)
+
+// redrivePolicy the RedrivePolicy attribute json
+type redrivePolicy struct {
+	DeadLetterTargetArn string      `json:"deadLetterTargetArn"`
+	MaxReceiveCount     json.Number `json:"maxReceiveCount"` //<< aws returns it as a string, but accepts either
+}
+
+// SetRedrivePolicy send messages to a dead letter queue after they've been received too many times
+//
+// arn - the dead letter queue's arn
+// max - how many times a message can be received before it's moved (1-1000)
+//
+// returns
+// - any error (sqsc.ErrInvalidMaxReceiveCount if max is out of range)
+func (c *SQSC) SetRedrivePolicy(arn string, max int) error {
+	return c.SetRedrivePolicyWithContext(context.Background(), arn, max)
+}
+
+// SetRedrivePolicyWithContext same as SetRedrivePolicy but with a context for cancellation
+func (c *SQSC) SetRedrivePolicyWithContext(ctx context.Context, arn string, max int) error {
+	if max < 1 || max > maxReceiveCount {
+		return ErrInvalidMaxReceiveCount
+	}
+
+	raw, err := json.Marshal(redrivePolicy{
+		DeadLetterTargetArn: arn,
+		MaxReceiveCount:     json.Number(strconv.Itoa(max)),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return c.SetAttributesWithContext(ctx, map[string]string{
+		sqs.QueueAttributeNameRedrivePolicy: string(raw),
+	})
+}
+
+// GetRedrivePolicy get the queue's dead letter queue config
+//
+// returns
+// - the dead letter queue's arn (blank if there is no redrive policy)
+// - the max receive count (0 if there is no redrive policy)
+// - any error
+func (c *SQSC) GetRedrivePolicy() (string, int, error) {
+	return c.GetRedrivePolicyWithContext(context.Background())
+}
+
+// GetRedrivePolicyWithContext same as GetRedrivePolicy but with a context for cancellation
+func (c *SQSC) GetRedrivePolicyWithContext(ctx context.Context) (string, int, error) {
+	attrs, err := c.AttributesWithContext(ctx, sqs.QueueAttributeNameRedrivePolicy)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	raw := attrs[sqs.QueueAttributeNameRedrivePolicy]
+
+	if raw == "" {
+		return "", 0, nil
+	}
+
+	var pol redrivePolicy
+
+	if err := json.Unmarshal([]byte(raw), &pol); err != nil {
+		return "", 0, fmt.Errorf("invalid redrive policy: %w", err)
+	}
+
+	max, err := strconv.Atoi(pol.MaxReceiveCount.String())
+
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid redrive policy max receive count: %w", err)
+	}
+
+	return pol.DeadLetterTargetArn, max, nil
+}
+
+// Redrive move messages from this queue (i.e. a dead letter queue) back to the source queue
+//
+// src - the source queue's url
+// max - the max number of messages to move (0 for all of them)
+//
+// messages are moved as is (body and attributes untouched) and only deleted from this queue once sent,
+// it stops once a receive comes back empty (so use a Wait for better odds of getting everything)
+//
+// returns
+// - how many messages were moved
+// - any error (messages that weren't sent are left in this queue)
+func (c *SQSC) Redrive(src string, max int) (int, error) {
+	return c.RedriveWithContext(context.Background(), src, max)
+}
+
+// RedriveWithContext same as Redrive but with a context for cancellation
+func (c *SQSC) RedriveWithContext(ctx context.Context, src string, max int) (int, error) {
+	vis := c.config.Timeout
+
+	// don't see the same messages again while moving them
+	if vis <= 0 {
+		vis = redriveVisibility
+	}
+
+	moved := 0
+
+	for max <= 0 || moved < max {
+		n := maxBatchSize
+
+		if max > 0 && max-moved < n {
+			n = max - moved
+		}
+
+		// raw receive so bodies/attributes aren't decoded (compressed and offloaded messages stay that way)
+		var rcv *sqs.ReceiveMessageOutput
+
+		err := c.call(ctx, "Redrive", func(ctx context.Context) (err error) {
+			rcv, err = c.sqs.ReceiveMessageWithContext(ctx, &sqs.ReceiveMessageInput{
+				QueueUrl:              aws.String(c.config.URL),
+				MaxNumberOfMessages:   aws.Int64(int64(n)),
+				VisibilityTimeout:     aws.Int64(int64(vis)),
+				WaitTimeSeconds:       aws.Int64(int64(c.config.Wait)),
+				AttributeNames:        []*string{aws.String(sqs.MessageSystemAttributeNameMessageGroupId)},
+				MessageAttributeNames: []*string{aws.String(sqs.QueueAttributeNameAll)},
+			})
+
+			return err
+		})
+
+		if err != nil {
+			return moved, err
+		}
+
+		if len(rcv.Messages) == 0 {
+			return moved, nil
+		}
+
+		ents := make([]*sqs.SendMessageBatchRequestEntry, 0, len(rcv.Messages))
+
+		for i, msg := range rcv.Messages {
+			ent := &sqs.SendMessageBatchRequestEntry{
+				Id:                aws.String(strconv.Itoa(i)),
+				MessageBody:       msg.Body,
+				MessageAttributes: msg.MessageAttributes,
+			}
+
+			// fifo messages keep their group (the message id makes sure it isn't dropped as a duplicate)
+			if gid := msg.Attributes[sqs.MessageSystemAttributeNameMessageGroupId]; gid != nil {
+				ent.MessageGroupId = gid
+				ent.MessageDeduplicationId = msg.MessageId
+			}
+
+			ents = append(ents, ent)
+		}
+
+		// wait our turn
+		if err := c.throttle(ctx, len(ents)); err != nil {
+			return moved, err
+		}
+
+		var snt *sqs.SendMessageBatchOutput
+
+		err = c.call(ctx, "Redrive", func(ctx context.Context) (err error) {
+			snt, err = c.sqs.SendMessageBatchWithContext(ctx, &sqs.SendMessageBatchInput{
+				QueueUrl: aws.String(src),
+				Entries:  ents,
+			})
+
+			return err
+		})
+
+		if err != nil {
+			return moved, err
+		}
+
+		// only delete what made it to the source queue
+		dels := make([]*sqs.DeleteMessageBatchRequestEntry, 0, len(snt.Successful))
+
+		for _, ent := range snt.Successful {
+			if i, ok := index(ent.Id, len(rcv.Messages)); ok {
+				dels = append(dels, &sqs.DeleteMessageBatchRequestEntry{
+					Id:            ent.Id,
+					ReceiptHandle: rcv.Messages[i].ReceiptHandle,
+				})
+			}
+		}
+
+		if len(dels) != 0 {
+			var del *sqs.DeleteMessageBatchOutput
+
+			err = c.call(ctx, "Redrive", func(ctx context.Context) (err error) {
+				del, err = c.sqs.DeleteMessageBatchWithContext(ctx, &sqs.DeleteMessageBatchInput{
+					QueueUrl: aws.String(c.config.URL),
+					Entries:  dels,
+				})
+
+				return err
+			})
+
+			if err != nil {
+				return moved, err
+			}
+
+			moved += len(del.Successful)
+
+			if len(del.Failed) != 0 {
+				return moved, batchError("Redrive", del.Failed[0])
+			}
+		}
+
+		if len(snt.Failed) != 0 {
+			return moved, batchError("Redrive", snt.Failed[0])
+		}
+
+		c.logf("sqsc: Redrive moved %d messages to %s", len(dels), src)
+	}
+
+	return moved, nil
+}
@@ -0,0 +1,183 @@
+package sqsc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// same formats as the aws extended clients so the messages are interchangeable
+const (
+	s3PointerClass  = "software.amazon.payloadoffloading.PayloadS3Pointer" //<< pointer message class
+	s3SizeAttribute = "ExtendedPayloadSize"                                //<< attribute marking an offloaded message
+	s3BucketMarker  = "-..s3BucketName..-"                                 //<< wraps the bucket in the receipt handle
+	s3KeyMarker     = "-..s3Key..-"                                        //<< wraps the key in the receipt handle
+)
+
+// s3Pointer where an offloaded body lives
+type s3Pointer struct {
+	Bucket string `json:"s3BucketName"`
+	Key    string `json:"s3Key"`
+}
+
+// offloading are large bodies being sent to s3?
+func (c *SQSC) offloading() bool {
+	return c.s3 != nil && c.config.S3Bucket != ""
+}
+
+// offload upload the body to s3 if it is over the threshold
+//
+// returns
+// - the body to send (a pointer if offloaded)
+// - the attributes to send (a copy with the size attribute if offloaded)
+// - any error
+func (c *SQSC) offload(ctx context.Context, bod string, attrs map[string]*sqs.MessageAttributeValue) (string, map[string]*sqs.MessageAttributeValue, error) {
+	max := c.config.S3Threshold
+
+	if max <= 0 {
+		max = maxMessageBytes
+	}
+
+	if !c.offloading() || size(bod, attrs) <= max {
+		return bod, attrs, nil
+	}
+
+	// random key so nothing collides
+	raw := make([]byte, 16)
+
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+
+	ptr := s3Pointer{
+		Bucket: c.config.S3Bucket,
+		Key:    hex.EncodeToString(raw),
+	}
+
+	err := c.call(ctx, "Offload", func(ctx context.Context) error {
+		_, err := c.s3.PutObjectWithContext(ctx, &s3.PutObjectInput{
+			Bucket: aws.String(ptr.Bucket),
+			Key:    aws.String(ptr.Key),
+			Body:   strings.NewReader(bod),
+		})
+
+		return err
+	})
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	pjs, err := json.Marshal([]interface{}{s3PointerClass, ptr})
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	return string(pjs), with(attrs, s3SizeAttribute, &sqs.MessageAttributeValue{
+		DataType:    aws.String("Number"),
+		StringValue: aws.String(strconv.Itoa(len(bod))),
+	}), nil
+}
+
+// onload swap an offloaded message's pointer for the real body from s3
+//
+// the pointer is embedded in the receipt handle so the object can be deleted with the message
+func (c *SQSC) onload(ctx context.Context, msg *sqs.Message) error {
+	if !c.offloading() || msg.MessageAttributes[s3SizeAttribute] == nil {
+		return nil
+	}
+
+	var pjs []json.RawMessage
+	var ptr s3Pointer
+
+	if err := json.Unmarshal([]byte(aws.StringValue(msg.Body)), &pjs); err != nil {
+		return err
+	}
+
+	if len(pjs) != 2 {
+		return errors.New("invalid s3 pointer")
+	}
+
+	if err := json.Unmarshal(pjs[1], &ptr); err != nil {
+		return err
+	}
+
+	var res *s3.GetObjectOutput
+
+	err := c.call(ctx, "Onload", func(ctx context.Context) (err error) {
+		res, err = c.s3.GetObjectWithContext(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(ptr.Bucket),
+			Key:    aws.String(ptr.Key),
+		})
+
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	bod, err := ioutil.ReadAll(res.Body)
+
+	if err != nil {
+		return err
+	}
+
+	msg.Body = aws.String(string(bod))
+	msg.ReceiptHandle = aws.String(s3BucketMarker + ptr.Bucket + s3BucketMarker + s3KeyMarker + ptr.Key + s3KeyMarker + aws.StringValue(msg.ReceiptHandle))
+
+	return nil
+}
+
+// unpoint split the s3 pointer from a receipt handle
+//
+// returns
+// - the real receipt handle
+// - the pointer (nil if the message was not offloaded)
+func unpoint(rh string) (string, *s3Pointer) {
+	bkt := strings.Split(rh, s3BucketMarker)
+
+	if len(bkt) != 3 || bkt[0] != "" {
+		return rh, nil
+	}
+
+	key := strings.Split(bkt[2], s3KeyMarker)
+
+	if len(key) != 3 || key[0] != "" {
+		return rh, nil
+	}
+
+	return key[2], &s3Pointer{
+		Bucket: bkt[1],
+		Key:    key[1],
+	}
+}
+
+// unload delete an offloaded body from s3 (no-op if nil)
+func (c *SQSC) unload(ctx context.Context, ptr *s3Pointer) error {
+	if ptr == nil || c.s3 == nil {
+		return nil
+	}
+
+	err := c.call(ctx, "Unload", func(ctx context.Context) error {
+		_, err := c.s3.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(ptr.Bucket),
+			Key:    aws.String(ptr.Key),
+		})
+
+		return err
+	})
+
+	return err
+}
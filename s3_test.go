@@ -0,0 +1,153 @@
+package sqsc
+
+import (
+	"bytes"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// bucket an in-memory s3 that only does the object calls offloading makes
+type bucket struct {
+	s3iface.S3API
+	mu   sync.Mutex
+	objs map[string][]byte //<< by bucket/key
+}
+
+func (b *bucket) PutObjectWithContext(_ aws.Context, inp *s3.PutObjectInput, _ ...request.Option) (*s3.PutObjectOutput, error) {
+	raw, err := ioutil.ReadAll(inp.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.objs[aws.StringValue(inp.Bucket)+"/"+aws.StringValue(inp.Key)] = raw
+
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (b *bucket) GetObjectWithContext(_ aws.Context, inp *s3.GetObjectInput, _ ...request.Option) (*s3.GetObjectOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	raw, ok := b.objs[aws.StringValue(inp.Bucket)+"/"+aws.StringValue(inp.Key)]
+
+	if !ok {
+		return nil, awserr.New(s3.ErrCodeNoSuchKey, "no such key", nil)
+	}
+
+	return &s3.GetObjectOutput{
+		Body: ioutil.NopCloser(bytes.NewReader(raw)),
+	}, nil
+}
+
+func (b *bucket) DeleteObjectWithContext(_ aws.Context, inp *s3.DeleteObjectInput, _ ...request.Option) (*s3.DeleteObjectOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.objs, aws.StringValue(inp.Bucket)+"/"+aws.StringValue(inp.Key))
+
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func TestUnpoint(t *testing.T) {
+	rh := s3BucketMarker + "bucket" + s3BucketMarker + s3KeyMarker + "key" + s3KeyMarker + "handle"
+
+	got, ptr := unpoint(rh)
+
+	if got != "handle" || ptr == nil || ptr.Bucket != "bucket" || ptr.Key != "key" {
+		t.Fatalf("got %q and %+v, want the handle, bucket, and key", got, ptr)
+	}
+
+	got, ptr = unpoint("handle")
+
+	if got != "handle" || ptr != nil {
+		t.Fatalf("got %q and %+v, want a plain handle untouched", got, ptr)
+	}
+}
+
+func TestOffload(t *testing.T) {
+	mem := NewMemory()
+	bkt := &bucket{objs: map[string][]byte{}}
+
+	c, _ := NewWithClient(mem, &Config{
+		URL:         memoryURL,
+		Timeout:     memoryVisibility,
+		S3Bucket:    "bucket",
+		S3Threshold: 100,
+	})
+
+	c.s3 = bkt
+
+	// under the threshold
+	if _, err := c.Produce("small", 0); err != nil {
+		t.Fatalf("Produce failed: %v", err)
+	}
+
+	if len(bkt.objs) != 0 {
+		t.Fatalf("offloaded %d objects, want none", len(bkt.objs))
+	}
+
+	msgs, err := c.Receive(1)
+
+	if err != nil || len(msgs) != 1 || msgs[0].Body != "small" {
+		t.Fatalf("got %+v and %v, want the small body", msgs, err)
+	}
+
+	if rh, ptr := unpoint(msgs[0].ReceiptHandle); ptr != nil {
+		t.Fatalf("got a pointer %+v in %q, want a plain handle", ptr, rh)
+	}
+
+	if _, err := c.Delete(msgs[0].ReceiptHandle); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	// over the threshold
+	big := strings.Repeat("x", 200)
+
+	if _, err := c.Produce(big, 0); err != nil {
+		t.Fatalf("Produce failed: %v", err)
+	}
+
+	if len(bkt.objs) != 1 {
+		t.Fatalf("offloaded %d objects, want 1", len(bkt.objs))
+	}
+
+	// only the pointer went to the queue
+	for _, msg := range mem.msgs {
+		if msg.body == big {
+			t.Fatal("expected the queue to get a pointer, not the body")
+		}
+	}
+
+	msgs, err = c.Receive(1)
+
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("Receive failed: %v %+v", err, msgs)
+	}
+
+	if msgs[0].Body != big {
+		t.Fatalf("got body %q, want the original", msgs[0].Body)
+	}
+
+	if _, err := c.Delete(msgs[0].ReceiptHandle); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	if len(bkt.objs) != 0 {
+		t.Fatalf("%d objects left after deleting, want none", len(bkt.objs))
+	}
+
+	if n, _ := c.MessagesNotVisible(); n != 0 {
+		t.Fatalf("%d messages left after deleting, want none", n)
+	}
+}
@@ -0,0 +1,110 @@
+package sqsc
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+)
+
+// AllowSNSTopic let an sns topic send messages to the queue (i.e. for sns -> sqs fan out)
+//
+// adds a statement to the queue's policy (keeping the existing ones), does nothing if the topic is already allowed
+//
+// arn - the topic's arn
+//
+// returns
+// - any error
+func (c *SQSC) AllowSNSTopic(arn string) error {
+	return c.AllowSNSTopicWithContext(context.Background(), arn)
+}
+
+// AllowSNSTopicWithContext same as AllowSNSTopic but with a context for cancellation
+func (c *SQSC) AllowSNSTopicWithContext(ctx context.Context, arn string) error {
+	que, err := c.ARNWithContext(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	pol, err := c.GetPolicyWithContext(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	// one statement per topic, so allowing it again is a no-op
+	sid := "sqscSNS" + checksum([]byte(arn))[:16]
+
+	for _, st := range pol.Statements {
+		if st.Sid == sid {
+			return nil
+		}
+	}
+
+	pol.Statements = append(pol.Statements, Statement{
+		Sid:       sid,
+		Effect:    "Allow",
+		Principal: Principal{"Service": {"sns.amazonaws.com"}},
+		Action:    Values{"sqs:SendMessage"},
+		Resource:  Values{que},
+		Condition: map[string]map[string]Values{
+			"ArnEquals": {"aws:SourceArn": {arn}},
+		},
+	})
+
+	return c.SetPolicyWithContext(ctx, pol)
+}
+
+// SubscribeToSNS subscribe the queue to an sns topic (allowing the topic to send to it first)
+//
+// cli - the sns client (i.e. sns.New(ses))
+// arn - the topic's arn
+// raw - deliver the raw message body (otherwise messages are wrapped in sns's json envelope)
+//
+// returns
+// - the subscription arn
+// - any error
+func (c *SQSC) SubscribeToSNS(cli snsiface.SNSAPI, arn string, raw bool) (string, error) {
+	return c.SubscribeToSNSWithContext(context.Background(), cli, arn, raw)
+}
+
+// SubscribeToSNSWithContext same as SubscribeToSNS but with a context for cancellation
+func (c *SQSC) SubscribeToSNSWithContext(ctx context.Context, cli snsiface.SNSAPI, arn string, raw bool) (string, error) {
+	if err := c.AllowSNSTopicWithContext(ctx, arn); err != nil {
+		return "", err
+	}
+
+	que, err := c.ARNWithContext(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	inp := sns.SubscribeInput{
+		TopicArn:              aws.String(arn),
+		Protocol:              aws.String("sqs"),
+		Endpoint:              aws.String(que),
+		ReturnSubscriptionArn: aws.Bool(true),
+	}
+
+	if raw {
+		inp.Attributes = map[string]*string{
+			"RawMessageDelivery": aws.String("true"),
+		}
+	}
+
+	var res *sns.SubscribeOutput
+
+	err = c.call(ctx, "SubscribeToSNS", func(ctx context.Context) (err error) {
+		res, err = cli.SubscribeWithContext(ctx, &inp)
+
+		return err
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(res.SubscriptionArn), nil
+}
@@ -0,0 +1,117 @@
+package sqsc
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"reflect"
+	"testing"
+)
+
+// policyQueue a memory queue with a policy attribute
+type policyQueue struct {
+	*Memory
+	policy string
+	sets   int
+}
+
+func (q *policyQueue) GetQueueAttributesWithContext(ctx aws.Context, inp *sqs.GetQueueAttributesInput, opts ...request.Option) (*sqs.GetQueueAttributesOutput, error) {
+	res, err := q.Memory.GetQueueAttributesWithContext(ctx, inp, opts...)
+
+	if err == nil && q.policy != "" {
+		res.Attributes[sqs.QueueAttributeNamePolicy] = aws.String(q.policy)
+	}
+
+	return res, err
+}
+
+func (q *policyQueue) SetQueueAttributesWithContext(_ aws.Context, inp *sqs.SetQueueAttributesInput, _ ...request.Option) (*sqs.SetQueueAttributesOutput, error) {
+	q.policy = aws.StringValue(inp.Attributes[sqs.QueueAttributeNamePolicy])
+	q.sets++
+
+	return &sqs.SetQueueAttributesOutput{}, nil
+}
+
+// subscriber an sns client that records the subscription
+type subscriber struct {
+	snsiface.SNSAPI
+	inp *sns.SubscribeInput
+}
+
+func (s *subscriber) SubscribeWithContext(_ aws.Context, inp *sns.SubscribeInput, _ ...request.Option) (*sns.SubscribeOutput, error) {
+	s.inp = inp
+
+	return &sns.SubscribeOutput{
+		SubscriptionArn: aws.String(aws.StringValue(inp.TopicArn) + ":subscription"),
+	}, nil
+}
+
+func TestAllowSNSTopic(t *testing.T) {
+	q := &policyQueue{
+		Memory: NewMemory(),
+		policy: `{"Version":"2012-10-17","Statement":[{"Sid":"existing","Effect":"Deny","NotPrincipal":"*","Action":["sqs:DeleteQueue","sqs:PurgeQueue"]}]}`,
+	}
+
+	c, _ := NewWithClient(q, &Config{URL: memoryURL})
+
+	before, err := c.GetPolicy()
+
+	if err != nil {
+		t.Fatalf("GetPolicy failed: %v", err)
+	}
+
+	if err := c.AllowSNSTopic("arn:aws:sns:us-east-1:000000000000:topic"); err != nil {
+		t.Fatalf("AllowSNSTopic failed: %v", err)
+	}
+
+	pol, err := c.GetPolicy()
+
+	if err != nil {
+		t.Fatalf("GetPolicy failed: %v", err)
+	}
+
+	if len(pol.Statements) != 2 || !reflect.DeepEqual(pol.Statements[0], before.Statements[0]) {
+		t.Fatalf("expected the existing statement to be kept, got %+v", pol.Statements)
+	}
+
+	st := pol.Statements[1]
+
+	if st.Effect != "Allow" || st.Condition["ArnEquals"]["aws:SourceArn"][0] != "arn:aws:sns:us-east-1:000000000000:topic" {
+		t.Fatalf("unexpected statement for the topic: %+v", st)
+	}
+
+	// already allowed
+	if err := c.AllowSNSTopic("arn:aws:sns:us-east-1:000000000000:topic"); err != nil {
+		t.Fatalf("AllowSNSTopic failed: %v", err)
+	}
+
+	if q.sets != 1 {
+		t.Fatalf("set the policy %d times, want 1", q.sets)
+	}
+}
+
+func TestSubscribeToSNS(t *testing.T) {
+	q := &policyQueue{Memory: NewMemory()}
+	c, _ := NewWithClient(q, &Config{URL: memoryURL})
+	s := &subscriber{}
+
+	sub, err := c.SubscribeToSNS(s, "arn:aws:sns:us-east-1:000000000000:topic", true)
+
+	if err != nil {
+		t.Fatalf("SubscribeToSNS failed: %v", err)
+	}
+
+	if sub != "arn:aws:sns:us-east-1:000000000000:topic:subscription" {
+		t.Fatalf("got subscription arn %q", sub)
+	}
+
+	if aws.StringValue(s.inp.Protocol) != "sqs" || aws.StringValue(s.inp.Attributes["RawMessageDelivery"]) != "true" {
+		t.Fatalf("unexpected subscription: %+v", s.inp)
+	}
+
+	if q.sets != 1 {
+		t.Fatal("expected the topic to be allowed first")
+	}
+}
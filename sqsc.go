@@ -1,62 +1,289 @@
 package sqsc
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"golang.org/x/time/rate"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxMessageBytes = 256 * 1024 //<< the aws max message size
+	maxDelay        = 900        //<< the aws max delay (seconds)
 )
 
 // SQSC the client
 type SQSC struct {
-	sqs    *sqs.SQS
-	config Config
+	sqs      sqsiface.SQSAPI
+	s3       s3iface.S3API
+	limiter  *rate.Limiter
+	config   Config
+	mu       sync.Mutex
+	closed   chan struct{}            //<< closed by Close
+	active   sync.WaitGroup           //<< running processors
+	sent     *sentCache               //<< recently produced keys for ProduceIdempotent
+	creds    *credentials.Credentials //<< expired and refreshed once on credential errors (nil if built with NewWithClient)
+	arn      string                   //<< the cached queue arn (blank until ARN succeeds)
+	snapshot statsCache               //<< last queue stats for ReceiveWithStats
 }
 
 // Config the client configs
 type Config struct {
-	ID       string //<< aws account id
-	Key      string //<< aws auth key - leave blank for no auth
-	Secret   string //<< aws account secret - leave blank for no auth
-	Region   string //<< aws region
-	Queue    string //<< queue name - not needed if url provided
-	URL      string //<< queue url - not needed if queue provided
-	Endpoint string //<< aws endpoint
-	Retries  int    //<< max retries
-	Timeout  int    //<< visibility timeout (seconds)
-	Wait     int    //<< wait time (seconds)
+	ID                     string               //<< aws account id that owns the queue - leave blank for your own account
+	Key                    string               //<< aws auth key - leave blank for the default credential chain
+	Secret                 string               //<< aws account secret - leave blank for the default credential chain
+	SessionToken           string               //<< aws session token for temporary key/secret (these can't be refreshed, use role arn or credentials for that)
+	Credentials            credentials.Provider //<< aws credentials provider - overrides key/secret when set
+	Anonymous              bool                 //<< use anonymous credentials (i.e. for localstack) - ignored if key/secret/credentials set
+	RoleARN                string               //<< iam role to assume (via sts) using the above credentials - leave blank to not assume a role
+	ExternalID             string               //<< external id for assuming the role (optional)
+	SessionName            string               //<< session name for assuming the role (optional)
+	Region                 string               //<< aws region
+	Queue                  string               //<< queue name - not needed if url provided
+	URL                    string               //<< queue url - not needed if queue provided
+	Endpoint               string               //<< aws endpoint - leave blank for the default regional endpoint
+	EndpointResolver       endpoints.Resolver   //<< per service endpoint resolution (i.e. sqs and s3 on different hosts) - overridden by the endpoint
+	S3ForcePathStyle       bool                 //<< use path style s3 urls (i.e. for localstack or minio)
+	DisableSSL             bool                 //<< use http for endpoints without a scheme (i.e. for localstack or elasticmq)
+	Compat                 bool                 //<< skip the request fields sqs emulators reject (i.e. for elasticmq)
+	HTTPClient             *http.Client         //<< http client for the aws calls (i.e. for proxies, tls, timeouts, pool sizes) - leave nil for the default
+	Retries                int                  //<< max retries (aws.Config.MaxRetries) - ignored if a retryer is set
+	Retryer                request.Retryer      //<< custom retryer (i.e. client.DefaultRetryer with throttle delays) - leave nil for the default
+	DisableRetries         bool                 //<< never retry (not even on expired credentials) - overrides the retries and retryer
+	Timeout                int                  //<< visibility timeout (seconds) - how long received messages stay hidden, NOT a request timeout
+	Wait                   int                  //<< long poll wait time (seconds, 0-20) - 0 means short poll (the queue's default wait is never used)
+	RequestTimeout         time.Duration        //<< http timeout for each aws call - must be longer than the wait - leave 0 for no timeout
+	OperationTimeout       time.Duration        //<< deadline for each operation (including retries) added to the context - must be longer than the wait - leave 0 for none
+	Create                 bool                 //<< skip the queue url lookup - use when creating the queue with CreateQueue
+	WaitForQueue           bool                 //<< keep retrying CreateQueue (for up to 60 seconds) if the queue was deleted recently
+	DefaultDelay           int                  //<< delay (seconds, 0-900) used by Send
+	HashDedup              bool                 //<< derive missing fifo deduplication ids from the sha-256 of the body
+	VerifyMD5              bool                 //<< check the md5s aws returns against the bodies/attributes sent and received
+	FetchAttributes        bool                 //<< receive all the message attributes by default (not just with ReceiveWithAttributes)
+	FetchSystemAttributes  bool                 //<< receive all the system attributes by default (not just with ReceiveWithAttributes)
+	DedupSize              int                  //<< max keys remembered by ProduceIdempotent - defaults to 1000
+	DedupTTL               time.Duration        //<< how long ProduceIdempotent remembers keys - defaults to 5 minutes
+	Buffer                 int                  //<< stream channel buffer size - leave 0 for unbuffered
+	EmptyReceiveBackoff    time.Duration        //<< how long streams sleep after an empty poll (reset once messages arrive) - leave 0 to poll again right away
+	EmptyReceiveBackoffMax time.Duration        //<< double the backoff after each empty poll up to this - leave 0 to not grow it
+	Heartbeat              int                  //<< extend the visibility timeout every this many seconds while processing - leave 0 to disable
+	Extension              int                  //<< visibility timeout set by each heartbeat (seconds) - defaults to the timeout
+	MaxReceives            int                  //<< give up on messages received more than this many times while processing - leave 0 to never give up
+	DeadLetterURL          string               //<< queue url to send given up messages to - leave blank to just delete them
+	MaxSize                int                  //<< max message size (bytes) including attributes - defaults to 262144 (the aws max)
+	S3Bucket               string               //<< offload large message bodies to this s3 bucket - leave blank to disable
+	S3Threshold            int                  //<< offload message bodies larger than this (bytes) - defaults to 262144
+	Compress               bool                 //<< gzip message bodies when producing (always decompressed when consuming)
+	RateLimit              float64              //<< max messages produced per second (bursts of up to 10) - leave 0 for no limit
+	Logger                 Logger               //<< log each operation (i.e. a *log.Logger) - leave nil for no logging
+	Metrics                Metrics              //<< record latencies and errors of each operation - leave nil for no metrics
+	Clock                  Clock                //<< time source for delays, heartbeats, and caches (i.e. a *FakeClock in tests) - leave nil for the real clock
 }
 
 // New creates a new client instance
 func New(cfg *Config) (*SQSC, error) {
-	// default is no-auth
-	crd := credentials.AnonymousCredentials
+	// fail fast on bad configs
+	if cfg.Region == "" {
+		return nil, ErrMissingRegion
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
 
-	// check if we do need to auth
-	if cfg.Key != "" && cfg.Secret != "" {
-		crd = credentials.NewStaticCredentials(cfg.Key, cfg.Secret, "")
+	// boot the session
+	ses, err := session.NewSession()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWithSession(ses, cfg)
+}
+
+// NewWithSession creates a new client instance using the given session (i.e. shared between many clients)
+//
+// the configs (region, credentials, endpoint, etc) override the session's when set
+func NewWithSession(ses *session.Session, cfg *Config) (*SQSC, error) {
+	// copy so we never mutate the caller's configs
+	cnf := *cfg
+
+	if err := cnf.validate(); err != nil {
+		return nil, err
+	}
+
+	// default is the session's credentials
+	var crd *credentials.Credentials
+
+	// check if we were given something else (providers other than static ones refresh themselves before expiring)
+	switch {
+	case cnf.Credentials != nil:
+		crd = credentials.NewCredentials(cnf.Credentials)
+	case cnf.Key != "" && cnf.Secret != "":
+		crd = credentials.NewStaticCredentials(cnf.Key, cnf.Secret, cnf.SessionToken)
+	case cnf.Anonymous:
+		crd = credentials.AnonymousCredentials
 	}
 
 	// build the aws configs
 	acf := aws.Config{
-		Region:      aws.String(cfg.Region),
 		Credentials: crd,
-		MaxRetries:  aws.Int(cfg.Retries),
-		Endpoint:    &cfg.Endpoint,
+		MaxRetries:  aws.Int(cnf.Retries),
 	}
 
-	// boot the session
-	ses, err := session.NewSession(&acf)
+	// leave it nil to use the session's region
+	if cnf.Region != "" {
+		acf.Region = aws.String(cnf.Region)
+	}
+
+	// custom backoff (uses its own max retries)
+	if cnf.Retryer != nil {
+		request.WithRetryer(&acf, cnf.Retryer)
+	}
+
+	// the caller does its own retrying
+	if cnf.DisableRetries {
+		request.WithRetryer(&acf, client.NoOpRetryer{})
+	}
+
+	// leave it nil to use the default regional endpoint
+	if cnf.Endpoint != "" {
+		acf.Endpoint = aws.String(cnf.Endpoint)
+	}
+
+	// leave it nil to use the session's resolver
+	if cnf.EndpointResolver != nil {
+		acf.EndpointResolver = cnf.EndpointResolver
+	}
+
+	if cnf.S3ForcePathStyle {
+		acf.S3ForcePathStyle = aws.Bool(true)
+	}
+
+	// local emulators usually only speak plain http
+	if cnf.DisableSSL {
+		acf.DisableSSL = aws.Bool(true)
+	}
+
+	// leave it nil to use the session's http client
+	if cnf.HTTPClient != nil {
+		acf.HTTPClient = cnf.HTTPClient
+	}
+
+	// copy the http client so the caller's is untouched
+	if cnf.RequestTimeout > 0 {
+		hcl := http.Client{}
+
+		if cnf.HTTPClient != nil {
+			hcl = *cnf.HTTPClient
+		}
+
+		hcl.Timeout = cnf.RequestTimeout
+		acf.HTTPClient = &hcl
+	}
+
+	// copy so the shared session is untouched (the credential cache is still shared)
+	ses = ses.Copy(&acf)
+
+	// assume the role on top of the base credentials
+	if cnf.RoleARN != "" {
+		acf.Credentials = stscreds.NewCredentials(ses, cnf.RoleARN, func(p *stscreds.AssumeRoleProvider) {
+			if cnf.ExternalID != "" {
+				p.ExternalID = aws.String(cnf.ExternalID)
+			}
+
+			if cnf.SessionName != "" {
+				p.RoleSessionName = cnf.SessionName
+			}
+		})
+
+		// fail now rather than on the first call
+		if _, err := acf.Credentials.Get(); err != nil {
+			return nil, fmt.Errorf("failed to assume role %s: %w", cnf.RoleARN, err)
+		}
+	}
 
 	// build the aws sqs client
-	cli := sqs.New(ses, &acf)
+	cli, err := NewWithClient(sqs.New(ses, &acf), &cnf)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// refresh these if they expire (the session's if none were configured)
+	cli.creds = acf.Credentials
+
+	if cli.creds == nil {
+		cli.creds = ses.Config.Credentials
+	}
+
+	// large message bodies go to s3
+	if cnf.S3Bucket != "" {
+		cli.s3 = s3.New(ses, &acf)
+	}
+
+	return cli, nil
+}
+
+// NewWithClient creates a new client instance using the given sqs client (i.e. a mock)
+//
+// only the queue related configs are used, the aws/auth configs are ignored (as is s3 offloading)
+func NewWithClient(cli sqsiface.SQSAPI, cfg *Config) (*SQSC, error) {
+	// copy so we never mutate the caller's configs
+	cnf := *cfg
+
+	if err := cnf.validate(); err != nil {
+		return nil, err
+	}
+
+	// build the struct
+	c := &SQSC{
+		sqs:    cli,
+		config: cnf,
+		closed: make(chan struct{}),
+	}
+
+	// real time unless testing
+	if c.config.Clock == nil {
+		c.config.Clock = systemClock{}
+	}
+
+	c.sent = newSentCache(cnf.DedupSize, cnf.DedupTTL, c.config.Clock)
 
 	// get the queue url
-	if cfg.URL == "" {
-		url, err := cli.GetQueueUrl(&sqs.GetQueueUrlInput{
-			QueueName:              aws.String(cfg.Queue),
-			QueueOwnerAWSAccountId: aws.String(cfg.ID),
+	if c.config.URL == "" && !c.config.Create {
+		var url *sqs.GetQueueUrlOutput
+
+		inp := sqs.GetQueueUrlInput{
+			QueueName: aws.String(cnf.Queue),
+		}
+
+		// only for other accounts' queues (some endpoints reject a blank one)
+		if cnf.ID != "" {
+			inp.QueueOwnerAWSAccountId = aws.String(cnf.ID)
+		}
+
+		err := c.call(context.Background(), "New", func(ctx context.Context) (err error) {
+			url, err = cli.GetQueueUrlWithContext(ctx, &inp)
+
+			return err
 		})
 
 		if err != nil {
@@ -67,14 +294,73 @@ func New(cfg *Config) (*SQSC, error) {
 			return nil, errors.New("failed to get queue url")
 		}
 
-		cfg.URL = *url.QueueUrl
+		c.config.URL = *url.QueueUrl
 	}
 
-	// build the struct
-	return &SQSC{
-		sqs:    cli,
-		config: *cfg,
-	}, err
+	// throttle producing
+	if cnf.RateLimit > 0 {
+		c.limiter = rate.NewLimiter(rate.Limit(cnf.RateLimit), maxBatchSize)
+	}
+
+	return c, nil
+}
+
+// validate check the queue configs before making any aws calls
+func (c *Config) validate() error {
+	// need a name to look up or create the queue
+	if c.Queue == "" && (c.URL == "" || c.Create) {
+		return ErrMissingQueue
+	}
+
+	if err := delay(c.DefaultDelay); err != nil {
+		return err
+	}
+
+	// always sent, so 0 is an explicit short poll rather than "unset"
+	if c.Wait < 0 || c.Wait > maxWait {
+		return ErrInvalidWait
+	}
+
+	// long polls would always time out
+	if c.RequestTimeout > 0 && c.RequestTimeout <= time.Duration(c.Wait)*time.Second {
+		return ErrInvalidRequestTimeout
+	}
+
+	if c.OperationTimeout > 0 && c.OperationTimeout <= time.Duration(c.Wait)*time.Second {
+		return ErrInvalidOperationTimeout
+	}
+
+	// streams would panic making the channel
+	if c.Buffer < 0 {
+		return ErrInvalidBuffer
+	}
+
+	if c.EmptyReceiveBackoff < 0 || c.EmptyReceiveBackoffMax < 0 {
+		return ErrInvalidBackoff
+	}
+
+	return nil
+}
+
+// URL the resolved queue url
+func (c *SQSC) URL() string {
+	return c.config.URL
+}
+
+// QueueName the queue name (taken from the url if not configured)
+func (c *SQSC) QueueName() string {
+	if c.config.Queue != "" {
+		return c.config.Queue
+	}
+
+	return c.config.URL[strings.LastIndex(c.config.URL, "/")+1:]
+}
+
+// Raw the underlying sqs client (*sqs.SQS unless built with NewWithClient) for anything not wrapped here
+//
+// note: calls made with it bypass the client's validation, compression/offloading, rate limiting, logging, and metrics
+func (c *SQSC) Raw() sqsiface.SQSAPI {
+	return c.sqs
 }
 
 // Produce produce a new message on the queue
@@ -86,15 +372,192 @@ func New(cfg *Config) (*SQSC, error) {
 // - the message id
 // - error
 func (c *SQSC) Produce(bod string, del int) (string, error) {
-	// send message
-	inp := sqs.SendMessageInput{
+	return c.ProduceWithContext(context.Background(), bod, del)
+}
+
+// ProduceWithContext same as Produce but with a context for cancellation
+func (c *SQSC) ProduceWithContext(ctx context.Context, bod string, del int) (string, error) {
+	return c.produce(ctx, &sqs.SendMessageInput{
 		MessageBody:  aws.String(bod),
 		QueueUrl:     aws.String(c.config.URL),
 		DelaySeconds: aws.Int64(int64(del)),
+	})
+}
+
+// Send same as Produce but uses the configured default delay
+func (c *SQSC) Send(bod string) (string, error) {
+	return c.SendWithContext(context.Background(), bod)
+}
+
+// SendWithContext same as Send but with a context for cancellation
+func (c *SQSC) SendWithContext(ctx context.Context, bod string) (string, error) {
+	return c.ProduceWithContext(ctx, bod, c.config.DefaultDelay)
+}
+
+// ProduceDelayed same as Produce but with the delay as a duration
+//
+// delay - the delay (0-15 minutes, truncated to seconds)
+//
+// returns
+// - the message id
+// - any error (sqsc.ErrDelayTooLong if over 15 minutes, sqsc.ErrInvalidDelay if negative)
+func (c *SQSC) ProduceDelayed(bod string, delay time.Duration) (string, error) {
+	return c.ProduceDelayedWithContext(context.Background(), bod, delay)
+}
+
+// ProduceDelayedWithContext same as ProduceDelayed but with a context for cancellation
+func (c *SQSC) ProduceDelayedWithContext(ctx context.Context, bod string, delay time.Duration) (string, error) {
+	return c.ProduceWithContext(ctx, bod, int(delay/time.Second))
+}
+
+// ProduceAt same as Produce but delivered at the given time (instead of after a delay)
+//
+// at - when the message becomes visible (now to 15 minutes from now, rounded to the second)
+//
+// returns
+// - the message id
+// - any error (sqsc.ErrDelayTooLong if over 15 minutes away, sqsc.ErrInvalidDelay if in the past)
+func (c *SQSC) ProduceAt(bod string, at time.Time) (string, error) {
+	return c.ProduceAtWithContext(context.Background(), bod, at)
+}
+
+// ProduceAtWithContext same as ProduceAt but with a context for cancellation
+func (c *SQSC) ProduceAtWithContext(ctx context.Context, bod string, at time.Time) (string, error) {
+	del := at.Sub(c.config.Clock.Now()).Round(time.Second)
+
+	return c.ProduceWithContext(ctx, bod, int(del/time.Second))
+}
+
+// ProduceWithAttributes same as Produce but with string message attributes
+//
+// attrs - the message attributes (name => value)
+func (c *SQSC) ProduceWithAttributes(bod string, del int, attrs map[string]string) (string, error) {
+	return c.ProduceWithAttributesWithContext(context.Background(), bod, del, attrs)
+}
+
+// ProduceWithAttributesWithContext same as ProduceWithAttributes but with a context for cancellation
+func (c *SQSC) ProduceWithAttributesWithContext(ctx context.Context, bod string, del int, attrs map[string]string) (string, error) {
+	// convert to string attributes
+	mav := make(map[string]*sqs.MessageAttributeValue, len(attrs))
+
+	for k, v := range attrs {
+		mav[k] = &sqs.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(v),
+		}
 	}
 
-	// send it
-	res, err := c.sqs.SendMessage(&inp)
+	return c.ProduceWithMessageAttributesWithContext(ctx, bod, del, mav)
+}
+
+// ProduceWithMessageAttributes same as Produce but with sdk message attributes (for number and binary types)
+//
+// attrs - the message attributes (name => value)
+func (c *SQSC) ProduceWithMessageAttributes(bod string, del int, attrs map[string]*sqs.MessageAttributeValue) (string, error) {
+	return c.ProduceWithMessageAttributesWithContext(context.Background(), bod, del, attrs)
+}
+
+// ProduceWithMessageAttributesWithContext same as ProduceWithMessageAttributes but with a context for cancellation
+func (c *SQSC) ProduceWithMessageAttributesWithContext(ctx context.Context, bod string, del int, attrs map[string]*sqs.MessageAttributeValue) (string, error) {
+	return c.produce(ctx, &sqs.SendMessageInput{
+		MessageBody:       aws.String(bod),
+		QueueUrl:          aws.String(c.config.URL),
+		DelaySeconds:      aws.Int64(int64(del)),
+		MessageAttributes: attrs,
+	})
+}
+
+// ProduceFIFO produce a new message on a fifo queue
+//
+// bod - the message body
+// gid - the message group id (required)
+// did - the deduplication id (leave blank if the queue uses content-based dedup, or Config.HashDedup is set)
+//
+// returns
+// - the message id
+// - error
+func (c *SQSC) ProduceFIFO(bod string, gid string, did string) (string, error) {
+	return c.ProduceFIFOWithContext(context.Background(), bod, gid, did)
+}
+
+// ProduceFIFOWithContext same as ProduceFIFO but with a context for cancellation
+func (c *SQSC) ProduceFIFOWithContext(ctx context.Context, bod string, gid string, did string) (string, error) {
+	return c.produce(ctx, c.fifoInput(bod, gid, did))
+}
+
+// ProduceResult the details of a produced message
+type ProduceResult struct {
+	ID              string //<< message id
+	SequenceNumber  string //<< sequence number (fifo queues only)
+	MD5OfBody       string //<< md5 of the body as sent (i.e. after compression/offloading)
+	MD5OfAttributes string //<< md5 of the message attributes as sent (blank if none)
+}
+
+// ProduceDetailed same as Produce but returns the sequence number and body md5 along with the message id
+func (c *SQSC) ProduceDetailed(bod string, del int) (ProduceResult, error) {
+	return c.ProduceDetailedWithContext(context.Background(), bod, del)
+}
+
+// ProduceDetailedWithContext same as ProduceDetailed but with a context for cancellation
+func (c *SQSC) ProduceDetailedWithContext(ctx context.Context, bod string, del int) (ProduceResult, error) {
+	return c.produceDetailed(ctx, &sqs.SendMessageInput{
+		MessageBody:  aws.String(bod),
+		QueueUrl:     aws.String(c.config.URL),
+		DelaySeconds: aws.Int64(int64(del)),
+	})
+}
+
+// ProduceFIFODetailed same as ProduceFIFO but returns the sequence number and body md5 along with the message id
+func (c *SQSC) ProduceFIFODetailed(bod string, gid string, did string) (ProduceResult, error) {
+	return c.ProduceFIFODetailedWithContext(context.Background(), bod, gid, did)
+}
+
+// ProduceFIFODetailedWithContext same as ProduceFIFODetailed but with a context for cancellation
+func (c *SQSC) ProduceFIFODetailedWithContext(ctx context.Context, bod string, gid string, did string) (ProduceResult, error) {
+	return c.produceDetailed(ctx, c.fifoInput(bod, gid, did))
+}
+
+// ProduceRaw same as Produce but returns the sdk's output as is (for anything not in ProduceResult)
+func (c *SQSC) ProduceRaw(bod string, del int) (*sqs.SendMessageOutput, error) {
+	return c.ProduceRawWithContext(context.Background(), bod, del)
+}
+
+// ProduceRawWithContext same as ProduceRaw but with a context for cancellation
+func (c *SQSC) ProduceRawWithContext(ctx context.Context, bod string, del int) (*sqs.SendMessageOutput, error) {
+	return c.send(ctx, &sqs.SendMessageInput{
+		MessageBody:  aws.String(bod),
+		QueueUrl:     aws.String(c.config.URL),
+		DelaySeconds: aws.Int64(int64(del)),
+	})
+}
+
+// fifoInput build the input for a fifo message
+func (c *SQSC) fifoInput(bod string, gid string, did string) *sqs.SendMessageInput {
+	// fifo queues do not support per-message delays
+	inp := sqs.SendMessageInput{
+		MessageBody: aws.String(bod),
+		QueueUrl:    aws.String(c.config.URL),
+	}
+
+	if gid != "" {
+		inp.MessageGroupId = aws.String(gid)
+	}
+
+	// same as content-based dedup, but client side
+	if did == "" && c.config.HashDedup {
+		did = dedup(bod)
+	}
+
+	if did != "" {
+		inp.MessageDeduplicationId = aws.String(did)
+	}
+
+	return &inp
+}
+
+// produce validate and send a message
+func (c *SQSC) produce(ctx context.Context, inp *sqs.SendMessageInput) (string, error) {
+	res, err := c.send(ctx, inp)
 
 	// default message id
 	id := ""
@@ -115,48 +578,205 @@ func (c *SQSC) Produce(bod string, del int) (string, error) {
 	return id, err
 }
 
+// produceDetailed send a message and convert the result
+func (c *SQSC) produceDetailed(ctx context.Context, inp *sqs.SendMessageInput) (ProduceResult, error) {
+	res, err := c.send(ctx, inp)
+
+	if err != nil {
+		return ProduceResult{}, err
+	}
+
+	return ProduceResult{
+		ID:              aws.StringValue(res.MessageId),
+		SequenceNumber:  aws.StringValue(res.SequenceNumber),
+		MD5OfBody:       aws.StringValue(res.MD5OfMessageBody),
+		MD5OfAttributes: aws.StringValue(res.MD5OfMessageAttributes),
+	}, nil
+}
+
+// send validate and send a message
+func (c *SQSC) send(ctx context.Context, inp *sqs.SendMessageInput) (*sqs.SendMessageOutput, error) {
+	// fifo queues need a group id
+	if c.fifo() && aws.StringValue(inp.MessageGroupId) == "" {
+		return nil, ErrMissingGroupID
+	}
+
+	if err := delay(int(aws.Int64Value(inp.DelaySeconds))); err != nil {
+		return nil, err
+	}
+
+	// compress it if configured
+	bod, attrs, err := c.encode(aws.StringValue(inp.MessageBody), inp.MessageAttributes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// send large bodies to s3
+	bod, attrs, err = c.offload(ctx, bod, attrs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// don't bother sending if aws will reject it
+	if err := c.fits(bod, attrs); err != nil {
+		return nil, err
+	}
+
+	// wait our turn
+	if err := c.throttle(ctx, 1); err != nil {
+		return nil, err
+	}
+
+	// copy so we never mutate the caller's input
+	cpy := *inp
+	cpy.MessageBody = aws.String(bod)
+	cpy.MessageAttributes = attrs
+
+	// send it
+	var res *sqs.SendMessageOutput
+
+	err = c.call(ctx, "Produce", func(ctx context.Context) (err error) {
+		res, err = c.sqs.SendMessageWithContext(ctx, &cpy)
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// catch corruption in transit
+	if err := c.verify(aws.StringValue(res.MessageId), bod, attrs, res.MD5OfMessageBody, res.MD5OfMessageAttributes); err != nil {
+		return nil, err
+	}
+
+	c.logf("sqsc: Produce sent message %s", aws.StringValue(res.MessageId))
+
+	return res, nil
+}
+
+// delay check the delay is within what aws allows
+func delay(del int) error {
+	if del < 0 {
+		return ErrInvalidDelay
+	}
+
+	if del > maxDelay {
+		return fmt.Errorf("%w: %d seconds", ErrDelayTooLong, del)
+	}
+
+	return nil
+}
+
+// dedup the deduplication id for a body (64 hex chars, under the aws max of 128)
+func dedup(bod string) string {
+	sum := sha256.Sum256([]byte(bod))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// fits check the message size against the max
+func (c *SQSC) fits(bod string, attrs map[string]*sqs.MessageAttributeValue) error {
+	max := c.config.MaxSize
+
+	if max <= 0 {
+		max = maxMessageBytes
+	}
+
+	if n := size(bod, attrs); n > max {
+		return fmt.Errorf("%w: %d bytes (max %d)", ErrMessageTooLarge, n, max)
+	}
+
+	return nil
+}
+
+// size the message size as counted by aws (body + attribute names, types, and values)
+func size(bod string, attrs map[string]*sqs.MessageAttributeValue) int {
+	n := len(bod)
+
+	for k, v := range attrs {
+		n += len(k) + len(aws.StringValue(v.DataType)) + len(aws.StringValue(v.StringValue)) + len(v.BinaryValue)
+	}
+
+	return n
+}
+
+// throttle block until n messages can be sent (no-op if no rate limit)
+func (c *SQSC) throttle(ctx context.Context, n int) error {
+	if c.limiter == nil {
+		return nil
+	}
+
+	return c.limiter.WaitN(ctx, n)
+}
+
+// fifo is the queue a fifo queue?
+func (c *SQSC) fifo() bool {
+	return strings.HasSuffix(c.config.URL, ".fifo")
+}
+
 // Consume consume a single message from the queue
 //
 // returns
 // - the message body
 // - the receipt handle (use for deleting messages)
-// - any error
+// - any error (sqsc.ErrNoMessages if the queue is empty, or no messages are visible)
 func (c *SQSC) Consume() (string, string, error) {
+	return c.ConsumeWithContext(context.Background())
+}
+
+// ConsumeWithContext same as Consume but with a context for cancellation
+//
+// use this to abort a long poll (i.e. on shutdown)
+func (c *SQSC) ConsumeWithContext(ctx context.Context) (string, string, error) {
 	// receive message
-	res, err := c.sqs.ReceiveMessage(&sqs.ReceiveMessageInput{
-		QueueUrl:          aws.String(c.config.URL),
-		VisibilityTimeout: aws.Int64(int64(c.config.Timeout)),
-		WaitTimeSeconds:   aws.Int64(int64(c.config.Wait)),
-	})
+	msg, err := c.ConsumeMessageWithContext(ctx)
 
 	// default message body
 	bod := ""
 	rh := ""
 
 	// get message body if we can
-	if res != nil && len(res.Messages) != 0 {
-		// get the body pointer first
-		msg := res.Messages[0]
-		ptr := msg.Body
+	if msg != nil {
+		bod = msg.Body
+		rh = msg.ReceiptHandle
+	}
 
-		// make sure we can dereference it
-		if ptr != nil {
-			// dereference it
-			bod = *ptr
-		}
+	// we done fam
+	return bod, rh, err
+}
 
-		// get the receipt handle pointer
-		ptr = msg.ReceiptHandle
+// ConsumeMessage consume a single message from the queue
+//
+// returns
+// - the message
+// - any error (sqsc.ErrNoMessages if the queue is empty, or no messages are visible)
+func (c *SQSC) ConsumeMessage() (*Message, error) {
+	return c.ConsumeMessageWithContext(context.Background())
+}
 
-		// make sure we can dereference it
-		if ptr != nil {
-			// dereference it
-			rh = *ptr
-		}
+// ConsumeMessageWithContext same as ConsumeMessage but with a context for cancellation
+func (c *SQSC) ConsumeMessageWithContext(ctx context.Context) (*Message, error) {
+	// receive message
+	msgs, err := c.receive(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(c.config.URL),
+		MaxNumberOfMessages: aws.Int64(1),
+		VisibilityTimeout:   aws.Int64(int64(c.config.Timeout)),
+		WaitTimeSeconds:     aws.Int64(int64(c.config.Wait)),
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
-	// we done fam
-	return bod, rh, err
+	// nothing to process
+	if len(msgs) == 0 {
+		return nil, ErrNoMessages
+	}
+
+	return &msgs[0], nil
 }
 
 // Delete delete a message from the queue
@@ -167,11 +787,30 @@ func (c *SQSC) Consume() (string, string, error) {
 // - the response (will be empty if success)
 // - any error
 func (c *SQSC) Delete(rh string) (string, error) {
+	return c.DeleteWithContext(context.Background(), rh)
+}
+
+// DeleteWithContext same as Delete but with a context for cancellation
+func (c *SQSC) DeleteWithContext(ctx context.Context, rh string) (string, error) {
+	// split off the s3 pointer if the body was offloaded
+	rh, ptr := unpoint(rh)
+
 	// delete that pesky message
-	res, err := c.sqs.DeleteMessage(&sqs.DeleteMessageInput{
-		QueueUrl:      aws.String(c.config.URL),
-		ReceiptHandle: &rh,
-	}) // no response returned when success
+	var res *sqs.DeleteMessageOutput
+
+	err := c.call(ctx, "Delete", func(ctx context.Context) (err error) {
+		res, err = c.sqs.DeleteMessageWithContext(ctx, &sqs.DeleteMessageInput{
+			QueueUrl:      aws.String(c.config.URL),
+			ReceiptHandle: &rh,
+		}) // no response returned when success
+
+		return err
+	})
+
+	// clean up the offloaded body
+	if err == nil {
+		err = c.unload(ctx, ptr)
+	}
 
 	// default body
 	bod := ""
@@ -182,6 +821,38 @@ func (c *SQSC) Delete(rh string) (string, error) {
 		bod = res.String()
 	}
 
+	if err == nil {
+		c.logf("sqsc: Delete deleted message %s", rh)
+	}
+
 	// we done fam
 	return bod, err
 }
+
+// ChangeVisibility change the visibility timeout of a message
+//
+// rh - the receipt handle (from sqsc.Consume())
+// sec - the new visibility timeout in seconds (0 to make it visible again now)
+//
+// returns
+// - any error
+func (c *SQSC) ChangeVisibility(rh string, sec int) error {
+	return c.ChangeVisibilityWithContext(context.Background(), rh, sec)
+}
+
+// ChangeVisibilityWithContext same as ChangeVisibility but with a context for cancellation
+func (c *SQSC) ChangeVisibilityWithContext(ctx context.Context, rh string, sec int) error {
+	rh, _ = unpoint(rh)
+
+	err := c.call(ctx, "ChangeVisibility", func(ctx context.Context) error {
+		_, err := c.sqs.ChangeMessageVisibilityWithContext(ctx, &sqs.ChangeMessageVisibilityInput{
+			QueueUrl:          aws.String(c.config.URL),
+			ReceiptHandle:     aws.String(rh),
+			VisibilityTimeout: aws.Int64(int64(sec)),
+		})
+
+		return err
+	})
+
+	return err
+}
@@ -0,0 +1,154 @@
+package sqsc
+
+import (
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// strictQueue a memory queue that rejects a blank queue owner account id (like some emulators)
+type strictQueue struct {
+	*Memory
+	owner *string //<< the last account id asked for
+}
+
+func (q *strictQueue) GetQueueUrlWithContext(ctx aws.Context, inp *sqs.GetQueueUrlInput, opts ...request.Option) (*sqs.GetQueueUrlOutput, error) {
+	q.owner = inp.QueueOwnerAWSAccountId
+
+	if q.owner != nil && *q.owner == "" {
+		return nil, awserr.New("InvalidParameterValue", "blank account id", nil)
+	}
+
+	return q.Memory.GetQueueUrlWithContext(ctx, inp, opts...)
+}
+
+func TestNewDoesNotMutateConfig(t *testing.T) {
+	cfg := &Config{
+		Key:      "key",
+		Secret:   "secret",
+		Region:   "us-east-1",
+		URL:      "http://localhost:4566/000000000000/queue",
+		Endpoint: "http://localhost:4566",
+	}
+
+	before := *cfg
+
+	if _, err := New(cfg); err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(*cfg, before) {
+		t.Fatalf("New changed the config: got %+v, want %+v", *cfg, before)
+	}
+
+	// the url lookup writes to the client's copy only
+	cfg = &Config{
+		Queue: "queue",
+	}
+
+	c, err := NewWithClient(NewMemory(), cfg)
+
+	if err != nil {
+		t.Fatalf("NewWithClient failed: %v", err)
+	}
+
+	if cfg.URL != "" || cfg.Clock != nil {
+		t.Fatalf("NewWithClient changed the config: %+v", *cfg)
+	}
+
+	if c.URL() == "" {
+		t.Fatal("expected the client to have the looked up url")
+	}
+}
+
+func TestNewUsesRegionalEndpoint(t *testing.T) {
+	c, err := New(&Config{
+		Key:    "key",
+		Secret: "secret",
+		Region: "eu-west-1",
+		URL:    "https://sqs.eu-west-1.amazonaws.com/000000000000/queue",
+	})
+
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	if got, want := c.Raw().(*sqs.SQS).Endpoint, "https://sqs.eu-west-1.amazonaws.com"; got != want {
+		t.Fatalf("got endpoint %q, want %q", got, want)
+	}
+
+	c, err = New(&Config{
+		Key:      "key",
+		Secret:   "secret",
+		Region:   "eu-west-1",
+		URL:      "http://localhost:4566/000000000000/queue",
+		Endpoint: "http://localhost:4566",
+	})
+
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	if got, want := c.Raw().(*sqs.SQS).Endpoint, "http://localhost:4566"; got != want {
+		t.Fatalf("got endpoint %q, want %q", got, want)
+	}
+}
+
+func TestProduceAt(t *testing.T) {
+	clk := NewFakeClock(time.Unix(0, 0))
+	c, _ := NewWithClient(NewMemoryWithClock(clk), &Config{URL: memoryURL, Clock: clk})
+
+	if _, err := c.ProduceAt("body", clk.Now().Add(16*time.Minute)); !errors.Is(err, ErrDelayTooLong) {
+		t.Fatalf("got %v, want %v", err, ErrDelayTooLong)
+	}
+
+	if _, err := c.ProduceAt("body", clk.Now().Add(-time.Minute)); !errors.Is(err, ErrInvalidDelay) {
+		t.Fatalf("got %v, want %v", err, ErrInvalidDelay)
+	}
+
+	// rounded to the nearest second
+	if _, err := c.ProduceAt("body", clk.Now().Add(1400*time.Millisecond)); err != nil {
+		t.Fatalf("ProduceAt failed: %v", err)
+	}
+
+	if n, _ := c.MessagesDelayed(); n != 1 {
+		t.Fatalf("%d messages delayed, want 1", n)
+	}
+
+	clk.Advance(time.Second)
+
+	if msgs, _ := c.Receive(1); len(msgs) != 1 {
+		t.Fatalf("got %+v, want the message once due", msgs)
+	}
+}
+
+func TestNewWithoutAccountID(t *testing.T) {
+	q := &strictQueue{Memory: NewMemory()}
+
+	c, err := NewWithClient(q, &Config{Queue: "queue"})
+
+	if err != nil {
+		t.Fatalf("NewWithClient failed: %v", err)
+	}
+
+	if q.owner != nil {
+		t.Fatalf("sent account id %q, want none", *q.owner)
+	}
+
+	if got, want := c.URL(), memoryURL+"/queue"; got != want {
+		t.Fatalf("got url %q, want %q", got, want)
+	}
+
+	if _, err := NewWithClient(q, &Config{Queue: "queue", ID: "123456789012"}); err != nil {
+		t.Fatalf("NewWithClient failed: %v", err)
+	}
+
+	if aws.StringValue(q.owner) != "123456789012" {
+		t.Fatalf("sent account id %v, want 123456789012", q.owner)
+	}
+}
@@ -0,0 +1,70 @@
+package sqsc
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"sync"
+	"time"
+)
+
+const statsTTL = 5 * time.Second //<< how long ReceiveWithStats reuses the queue stats
+
+// Stats a snapshot of the queue's backlog
+type Stats struct {
+	ApproximateNumberOfMessages int       //<< approximate number of visible messages
+	FetchedAt                   time.Time //<< when the snapshot was taken (up to 5 seconds ago)
+}
+
+// statsCache the last stats snapshot
+type statsCache struct {
+	mu  sync.Mutex
+	val Stats
+}
+
+// ReceiveWithStats same as Receive but also returns a snapshot of the queue's backlog (i.e. for scaling workers)
+//
+// the snapshot is cached for 5 seconds so it doesn't cost an extra call per receive
+//
+// returns
+// - the messages (even if getting the stats failed, so they can still be handled)
+// - the stats
+// - any error
+func (c *SQSC) ReceiveWithStats(n int64) ([]Message, Stats, error) {
+	return c.ReceiveWithStatsWithContext(context.Background(), n)
+}
+
+// ReceiveWithStatsWithContext same as ReceiveWithStats but with a context for cancellation
+func (c *SQSC) ReceiveWithStatsWithContext(ctx context.Context, n int64) ([]Message, Stats, error) {
+	msgs, err := c.ReceiveWithContext(ctx, n)
+
+	if err != nil {
+		return msgs, Stats{}, err
+	}
+
+	sts, err := c.stats(ctx)
+
+	return msgs, sts, err
+}
+
+// stats the cached stats (refreshed if stale)
+func (c *SQSC) stats(ctx context.Context) (Stats, error) {
+	c.snapshot.mu.Lock()
+	defer c.snapshot.mu.Unlock()
+
+	if c.config.Clock.Now().Sub(c.snapshot.val.FetchedAt) < statsTTL {
+		return c.snapshot.val, nil
+	}
+
+	num, err := c.count(ctx, sqs.QueueAttributeNameApproximateNumberOfMessages)
+
+	if err != nil {
+		return Stats{}, err
+	}
+
+	c.snapshot.val = Stats{
+		ApproximateNumberOfMessages: num,
+		FetchedAt:                   c.config.Clock.Now(),
+	}
+
+	return c.snapshot.val, nil
+}
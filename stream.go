@@ -0,0 +1,119 @@
+package sqsc
+
+import (
+	"context"
+	"time"
+)
+
+// Stream continuously receive messages from the queue until the context is cancelled
+//
+// polls using the configured wait and visibility timeout (and sleeps for Config.EmptyReceiveBackoff after empty polls)
+//
+// returns
+// - the messages channel (unbuffered unless Config.Buffer is set)
+// - the errors channel (polling errors, the stream keeps going)
+//
+// note: both channels must be drained, they are closed once the context is cancelled (or the client is closed)
+//
+// note: on close the messages already received are still sent, on cancel they're made visible again right away
+func (c *SQSC) Stream(ctx context.Context) (<-chan Message, <-chan error) {
+	msgs := make(chan Message, c.config.Buffer)
+	errs := make(chan error)
+
+	// stop polling on close too
+	parent := ctx
+	ctx, cancel := c.closable(ctx)
+
+	go func() {
+		defer cancel()
+		defer close(msgs)
+		defer close(errs)
+
+		// idle backoff (reset once messages arrive)
+		bo := c.config.EmptyReceiveBackoff
+
+		for ctx.Err() == nil {
+			rcv, err := c.ReceiveWithContext(ctx, maxBatchSize)
+
+			// don't hammer an idle queue
+			if len(rcv) == 0 && err == nil && bo > 0 {
+				select {
+				case <-c.config.Clock.After(bo):
+				case <-ctx.Done():
+					return
+				}
+
+				bo = c.backoff(bo)
+
+				continue
+			}
+
+			bo = c.config.EmptyReceiveBackoff
+
+			// still send the good ones on a partial error
+			for i, msg := range rcv {
+				select {
+				case msgs <- msg:
+				case <-ctx.Done():
+					c.drain(parent, msgs, rcv[i:])
+
+					return
+				}
+			}
+
+			if err != nil {
+				// cancelled mid-poll is not an error
+				if ctx.Err() != nil {
+					return
+				}
+
+				select {
+				case errs <- err:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return msgs, errs
+}
+
+// drain send the received messages that are left once the stream stops (i.e. closed while sending a batch)
+//
+// until the context is cancelled, then the rest are made visible again instead of waiting out the visibility timeout
+func (c *SQSC) drain(ctx context.Context, msgs chan<- Message, rest []Message) {
+	for i, msg := range rest {
+		select {
+		case msgs <- msg:
+			continue
+		case <-ctx.Done():
+		}
+
+		rhs := make([]string, 0, len(rest)-i)
+
+		for _, msg := range rest[i:] {
+			rhs = append(rhs, msg.ReceiptHandle)
+		}
+
+		// best effort, if it fails they're just redelivered after the timeout
+		_, _ = c.ChangeVisibilityBatchWithContext(context.Background(), rhs, 0)
+
+		return
+	}
+}
+
+// backoff the next empty receive backoff (doubled up to the max, or the same if there is no max)
+func (c *SQSC) backoff(bo time.Duration) time.Duration {
+	if bo >= c.config.EmptyReceiveBackoffMax {
+		return bo
+	}
+
+	bo *= 2
+
+	if bo > c.config.EmptyReceiveBackoffMax {
+		bo = c.config.EmptyReceiveBackoffMax
+	}
+
+	return bo
+}
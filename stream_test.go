@@ -0,0 +1,91 @@
+package sqsc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	c := &SQSC{config: Config{EmptyReceiveBackoff: time.Second, EmptyReceiveBackoffMax: 4 * time.Second}}
+
+	for _, tc := range []struct {
+		in   time.Duration
+		want time.Duration
+	}{
+		{in: time.Second, want: 2 * time.Second},
+		{in: 3 * time.Second, want: 4 * time.Second},
+		{in: 4 * time.Second, want: 4 * time.Second},
+	} {
+		if got := c.backoff(tc.in); got != tc.want {
+			t.Fatalf("backoff(%s) = %s, want %s", tc.in, got, tc.want)
+		}
+	}
+
+	// doesn't grow without a max
+	c.config.EmptyReceiveBackoffMax = 0
+
+	if got := c.backoff(time.Second); got != time.Second {
+		t.Fatalf("backoff(1s) = %s, want 1s", got)
+	}
+}
+
+func TestStreamBacksOffWhenEmpty(t *testing.T) {
+	clk := NewFakeClock(time.Unix(0, 0))
+
+	c, _ := NewWithClient(NewMemoryWithClock(clk), &Config{
+		URL:                    memoryURL,
+		Timeout:                memoryVisibility,
+		Clock:                  clk,
+		EmptyReceiveBackoff:    time.Second,
+		EmptyReceiveBackoffMax: 4 * time.Second,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msgs, _ := c.Stream(ctx)
+
+	// sleeping after the empty poll
+	for clk.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := c.Produce("body", 0); err != nil {
+		t.Fatalf("Produce failed: %v", err)
+	}
+
+	select {
+	case msg := <-msgs:
+		t.Fatalf("got %+v before the backoff was over", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	clk.Advance(time.Second)
+
+	select {
+	case msg := <-msgs:
+		if msg.Body != "body" {
+			t.Fatalf("got body %q, want %q", msg.Body, "body")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("didn't poll again after the backoff")
+	}
+}
+
+func TestStreamNegativeBuffer(t *testing.T) {
+	if _, err := NewWithClient(NewMemory(), &Config{URL: memoryURL, Buffer: -1}); err != ErrInvalidBuffer {
+		t.Fatalf("got %v, want %v", err, ErrInvalidBuffer)
+	}
+}
+
+func TestNegativeBackoff(t *testing.T) {
+	for _, cfg := range []*Config{
+		{URL: memoryURL, EmptyReceiveBackoff: -time.Second},
+		{URL: memoryURL, EmptyReceiveBackoffMax: -time.Second},
+	} {
+		if _, err := NewWithClient(NewMemory(), cfg); err != ErrInvalidBackoff {
+			t.Fatalf("%+v: got %v, want %v", *cfg, err, ErrInvalidBackoff)
+		}
+	}
+}
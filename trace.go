@@ -0,0 +1,44 @@
+package sqsc
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// traceKey the context key for the trace header
+type traceKey struct{}
+
+// WithTraceHeader add an x-ray trace header (i.e. "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1") to the context for ProduceTraced
+func WithTraceHeader(ctx context.Context, hdr string) context.Context {
+	return context.WithValue(ctx, traceKey{}, hdr)
+}
+
+// TraceHeader the x-ray trace header in the context (blank if none)
+func TraceHeader(ctx context.Context) string {
+	hdr, _ := ctx.Value(traceKey{}).(string)
+
+	return hdr
+}
+
+// ProduceTraced same as Produce (with no delay) but sends the context's trace header (see WithTraceHeader) as the AWSTraceHeader system attribute
+//
+// the consumer gets it back as Message.TraceHeader
+func (c *SQSC) ProduceTraced(ctx context.Context, bod string) (string, error) {
+	inp := sqs.SendMessageInput{
+		MessageBody: aws.String(bod),
+		QueueUrl:    aws.String(c.config.URL),
+	}
+
+	// no trace header, just a normal message
+	if hdr := TraceHeader(ctx); hdr != "" {
+		inp.MessageSystemAttributes = map[string]*sqs.MessageSystemAttributeValue{
+			sqs.MessageSystemAttributeNameAwstraceHeader: {
+				DataType:    aws.String("String"),
+				StringValue: aws.String(hdr),
+			},
+		}
+	}
+
+	return c.produce(ctx, &inp)
+}